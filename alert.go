@@ -0,0 +1,272 @@
+package tracking51
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 包裹异常检测
+
+// Severity 告警级别
+type Severity int
+
+const (
+	InfoSeverity     Severity = iota // 提示
+	WarningSeverity                  // 警告
+	CriticalSeverity                 // 严重
+)
+
+func (s Severity) String() string {
+	switch s {
+	case InfoSeverity:
+		return "info"
+	case WarningSeverity:
+		return "warning"
+	case CriticalSeverity:
+		return "critical"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Alert 告警
+type Alert struct {
+	Rule     string   `json:"rule"`     // 规则名称
+	Severity Severity `json:"severity"` // 告警级别
+	Message  string   `json:"message"`  // 告警信息
+}
+
+// AlertRule 告警规则，Check 返回告警信息（使用 lang 指定的语言）和是否触发告警
+type AlertRule struct {
+	Name     string                                                                  // 规则名称
+	Severity Severity                                                                // 告警级别
+	Check    func(track Track, now time.Time, lang string) (message string, ok bool) // 检查函数
+}
+
+// Threshold 阈值，优先使用目的国的阈值，其次是物流商的阈值，都没有设置时使用默认值
+type Threshold struct {
+	Default      int            // 默认值
+	Couriers     map[string]int // 物流商简码 => 阈值
+	Destinations map[string]int // 目的国二字简码 => 阈值
+}
+
+// For 返回包裹对应的阈值
+func (t Threshold) For(track Track) int {
+	if v, ok := t.Destinations[strings.ToUpper(track.Destination)]; ok {
+		return v
+	}
+	if v, ok := t.Couriers[track.CourierCode]; ok {
+		return v
+	}
+	return t.Default
+}
+
+// 是否为最终状态（已签收、运输过久）
+func isFinalStatus(status string) bool {
+	return status == StatusDelivered || status == StatusExpired
+}
+
+// 最新的物流节点
+func latestCheckpoint(track Track) (TimelineEvent, bool) {
+	events := MergeTimeline(track, TimelineOptions{Descending: true})
+	if len(events) == 0 {
+		return TimelineEvent{}, false
+	}
+	return events[0], true
+}
+
+// 文字中是否包含关键词（不区分大小写）
+func containsKeyword(s string, keywords []string) (string, bool) {
+	s = strings.ToLower(s)
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// StayTimeRule 物流信息超过指定天数未更新（已签收、运输过久的包裹除外）
+func StayTimeRule(days Threshold, severity Severity) AlertRule {
+	return AlertRule{
+		Name:     "stay_time",
+		Severity: severity,
+		Check: func(track Track, now time.Time, lang string) (string, bool) {
+			n := days.For(track)
+			if n <= 0 || isFinalStatus(track.DeliveryStatus) || track.StayTime <= n {
+				return "", false
+			}
+			return formatMessage(lang, "alert_stay_time", map[string]interface{}{"days": track.StayTime, "threshold": n}), true
+		},
+	}
+}
+
+// StatusRule 包裹处于指定的状态
+func StatusRule(severity Severity, statuses ...string) AlertRule {
+	return AlertRule{
+		Name:     "status",
+		Severity: severity,
+		Check: func(track Track, now time.Time, lang string) (string, bool) {
+			for _, status := range statuses {
+				if track.DeliveryStatus == status {
+					return formatMessage(lang, "alert_status", map[string]interface{}{"status": DeliveryStatusName(status, lang)}), true
+				}
+			}
+			return "", false
+		},
+	}
+}
+
+var returnKeywords = []string{"return to sender", "returned to sender", "returning to sender", "return to shipper", "退回", "退件", "devuelto", "retour"}
+
+// ReturnToSenderRule 包裹被退回
+func ReturnToSenderRule(severity Severity) AlertRule {
+	return AlertRule{
+		Name:     "return_to_sender",
+		Severity: severity,
+		Check: func(track Track, now time.Time, lang string) (string, bool) {
+			for _, event := range MergeTimeline(track, TimelineOptions{Descending: true}) {
+				if keyword, ok := containsKeyword(event.TrackingDetail, returnKeywords); ok {
+					return formatMessage(lang, "alert_return_to_sender", map[string]interface{}{"keyword": keyword, "detail": event.TrackingDetail}), true
+				}
+			}
+			return "", false
+		},
+	}
+}
+
+var customsHoldKeywords = []string{"held by customs", "customs hold", "held in customs", "clearance delay", "customs inspection", "海关扣留", "海关查验", "清关延误", "retenido en aduana"}
+
+// CustomsHoldRule 最新的物流节点显示包裹被海关扣留（已签收、运输过久的包裹除外）
+func CustomsHoldRule(severity Severity) AlertRule {
+	return AlertRule{
+		Name:     "customs_hold",
+		Severity: severity,
+		Check: func(track Track, now time.Time, lang string) (string, bool) {
+			if isFinalStatus(track.DeliveryStatus) {
+				return "", false
+			}
+			event, ok := latestCheckpoint(track)
+			if !ok {
+				return "", false
+			}
+			if keyword, ok := containsKeyword(event.TrackingDetail, customsHoldKeywords); ok {
+				return formatMessage(lang, "alert_customs_hold", map[string]interface{}{"keyword": keyword, "detail": event.TrackingDetail}), true
+			}
+			return "", false
+		},
+	}
+}
+
+// NoFirstScanRule 发货（没有发货时间时使用创建时间）超过指定天数仍然没有物流信息
+func NoFirstScanRule(days Threshold, severity Severity) AlertRule {
+	return AlertRule{
+		Name:     "no_first_scan",
+		Severity: severity,
+		Check: func(track Track, now time.Time, lang string) (string, bool) {
+			n := days.For(track)
+			if n <= 0 || len(track.OriginInfo.TrackInfo) != 0 || len(track.DestinationInfo.TrackInfo) != 0 {
+				return "", false
+			}
+			shippedAt := firstTime(track.OrderCreateTime, track.CreatedAt)
+			if shippedAt.IsZero() {
+				return "", false
+			}
+			if d := int(now.Sub(shippedAt).Hours() / 24); d > n {
+				return formatMessage(lang, "alert_no_first_scan", map[string]interface{}{"days": d, "threshold": n}), true
+			}
+			return "", false
+		},
+	}
+}
+
+// DefaultAlertRules 默认的告警规则
+func DefaultAlertRules() []AlertRule {
+	return []AlertRule{
+		StayTimeRule(Threshold{Default: 7}, WarningSeverity),
+		StatusRule(CriticalSeverity, StatusException),
+		StatusRule(WarningSeverity, StatusUndelivered, StatusExpired),
+		ReturnToSenderRule(CriticalSeverity),
+		CustomsHoldRule(WarningSeverity),
+		NoFirstScanRule(Threshold{Default: 3}, WarningSeverity),
+	}
+}
+
+// AlertReport 包裹的告警报告
+type AlertReport struct {
+	TrackingNumber string  `json:"tracking_number"` // 包裹物流单号
+	CourierCode    string  `json:"courier_code"`    // 物流商对应的唯一简码
+	Alerts         []Alert `json:"alerts"`          // 触发的告警
+}
+
+// HasAlerts 是否有告警
+func (r AlertReport) HasAlerts() bool {
+	return len(r.Alerts) != 0
+}
+
+// Severity 最高的告警级别
+func (r AlertReport) Severity() Severity {
+	severity := InfoSeverity
+	for _, alert := range r.Alerts {
+		if alert.Severity > severity {
+			severity = alert.Severity
+		}
+	}
+	return severity
+}
+
+// AlertEngine 告警规则引擎
+type AlertEngine struct {
+	rules    []AlertRule
+	language string
+	now      func() time.Time
+}
+
+// NewAlertEngine 创建告警规则引擎，没有传入规则时使用默认规则
+func NewAlertEngine(rules ...AlertRule) *AlertEngine {
+	if len(rules) == 0 {
+		rules = DefaultAlertRules()
+	}
+	return &AlertEngine{rules: rules, language: ChineseLanguage, now: time.Now}
+}
+
+// Add 添加规则
+func (e *AlertEngine) Add(rule AlertRule) *AlertEngine {
+	e.rules = append(e.rules, rule)
+	return e
+}
+
+// WithLanguage 设置告警信息的语言（可以使用 Tracking51.Language()），默认为中文
+func (e *AlertEngine) WithLanguage(lang string) *AlertEngine {
+	e.language = lang
+	return e
+}
+
+// Evaluate 检查包裹，返回触发的告警
+func (e *AlertEngine) Evaluate(track Track) AlertReport {
+	report := AlertReport{TrackingNumber: track.TrackingNumber, CourierCode: track.CourierCode}
+	now := e.now()
+	for _, rule := range e.rules {
+		if message, ok := rule.Check(track, now, e.language); ok {
+			report.Alerts = append(report.Alerts, Alert{Rule: rule.Name, Severity: rule.Severity, Message: message})
+		}
+	}
+	return report
+}
+
+// EvaluateAll 检查读取到的所有包裹，只返回有告警的报告
+func (e *AlertEngine) EvaluateAll(reader TrackReader) ([]AlertReport, error) {
+	var reports []AlertReport
+	for {
+		track, err := reader.Read()
+		if err == io.EOF {
+			return reports, nil
+		} else if err != nil {
+			return reports, err
+		}
+		if report := e.Evaluate(track); report.HasAlerts() {
+			reports = append(reports, report)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package tracking51
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestAlertEngine_Evaluate(t *testing.T) {
+	now := time.Date(2022, 7, 20, 0, 0, 0, 0, time.Local)
+	engine := NewAlertEngine(
+		StayTimeRule(Threshold{Default: 7, Couriers: map[string]int{"usps": 3}, Destinations: map[string]int{"MX": 15}}, WarningSeverity),
+		StatusRule(CriticalSeverity, StatusException),
+		ReturnToSenderRule(CriticalSeverity),
+		CustomsHoldRule(WarningSeverity),
+		NoFirstScanRule(Threshold{Default: 3}, WarningSeverity),
+	).Add(AlertRule{
+		Name:     "vip",
+		Severity: InfoSeverity,
+		Check: func(track Track, now time.Time, lang string) (string, bool) {
+			return "VIP 客户", track.CustomerEmail == "vip@example.com"
+		},
+	})
+	engine.now = func() time.Time { return now }
+
+	for _, v := range []struct {
+		track    Track
+		rules    []string
+		severity Severity
+	}{
+		{Track{CourierCode: "usps", StayTime: 5}, []string{"stay_time"}, WarningSeverity},
+		{Track{CourierCode: "usps", Destination: "mx", StayTime: 5}, nil, InfoSeverity},
+		{Track{CourierCode: "usps", StayTime: 5, DeliveryStatus: StatusDelivered}, nil, InfoSeverity},
+		{Track{DeliveryStatus: StatusException, OriginInfo: Information{TrackInfo: []TrackInformation{
+			{CheckpointDate: "2022-07-10 10:00:00", TrackingDetail: "Return to sender"},
+		}}}, []string{"status", "return_to_sender"}, CriticalSeverity},
+		{Track{DeliveryStatus: StatusTransit, DestinationInfo: Information{TrackInfo: []TrackInformation{
+			{CheckpointDate: "2022-07-12 10:00:00", TrackingDetail: "Held by customs"},
+			{CheckpointDate: "2022-07-10 10:00:00", TrackingDetail: "Arrived"},
+		}}}, []string{"customs_hold"}, WarningSeverity},
+		{Track{OrderCreateTime: "2022-07-10 10:00:00", CustomerEmail: "vip@example.com"}, []string{"no_first_scan", "vip"}, WarningSeverity},
+	} {
+		report := engine.Evaluate(v.track)
+		if len(report.Alerts) != len(v.rules) {
+			t.Errorf("expected alerts %v, got %#v", v.rules, report.Alerts)
+			continue
+		}
+		for i, rule := range v.rules {
+			if report.Alerts[i].Rule != rule {
+				t.Errorf("expected rule %s, got %s", rule, report.Alerts[i].Rule)
+			}
+		}
+		if report.Severity() != v.severity {
+			t.Errorf("expected severity %s, got %s", v.severity, report.Severity())
+		}
+	}
+
+	engine.WithLanguage(EnglishLanguage)
+	if report := engine.Evaluate(Track{CourierCode: "usps", StayTime: 5}); report.Alerts[0].Message != "No tracking update for 5 days (threshold: 3 days)" {
+		t.Errorf("unexpected message: %s", report.Alerts[0].Message)
+	}
+	if report := engine.Evaluate(Track{DeliveryStatus: StatusException}); report.Alerts[0].Message != "The package status is "+DeliveryStatusName(StatusException, EnglishLanguage) {
+		t.Errorf("unexpected message: %s", report.Alerts[0].Message)
+	}
+
+	reports, err := NewAlertEngine().EvaluateAll(NewSliceTrackReader([]Track{{StayTime: 30}, {StayTime: 1}}))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Errorf("expected 1 report, got %d", len(reports))
+	}
+}
@@ -0,0 +1,242 @@
+package tracking51
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 时效分析
+
+// AnalyticsDimension 分组维度
+type AnalyticsDimension string
+
+const (
+	CourierDimension          AnalyticsDimension = "courier"           // 物流商
+	DestinationDimension      AnalyticsDimension = "destination"       // 目的国
+	LogisticsChannelDimension AnalyticsDimension = "logistics_channel" // 物流渠道
+	MonthDimension            AnalyticsDimension = "month"             // 月份（根据上网时间，其次是发货时间、创建时间）
+)
+
+// SLA 时效标准（天）
+type SLA struct {
+	Default int            // 默认时效，为 0 表示不计算准时率
+	Lanes   map[string]int // 线路 => 时效，线路格式为“物流商简码:发件国-目的国”（例子：usps:CN-US），物流商简码和发件国可以使用 * 匹配所有
+}
+
+// For 返回包裹对应的时效，依次匹配“物流商:发件国-目的国”、“物流商:*-目的国”、“*:发件国-目的国”、“*:*-目的国”
+func (s SLA) For(track Track) int {
+	original, destination := countryCode(track.Original), countryCode(track.Destination)
+	for _, key := range []string{
+		track.CourierCode + ":" + original + "-" + destination,
+		track.CourierCode + ":*-" + destination,
+		"*:" + original + "-" + destination,
+		"*:*-" + destination,
+	} {
+		if v, ok := s.Lanes[key]; ok {
+			return v
+		}
+	}
+	return s.Default
+}
+
+// AnalyticsOptions 分析选项
+type AnalyticsOptions struct {
+	GroupBy []AnalyticsDimension // 分组维度，为空时不分组
+	SLA     SLA                  // 时效标准
+}
+
+// AnalyticsGroup 分组统计结果
+type AnalyticsGroup struct {
+	Keys             []string `json:"keys"`               // 分组维度的值（与 GroupBy 的顺序一致）
+	Total            int      `json:"total"`              // 包裹数量
+	Delivered        int      `json:"delivered"`          // 已签收数量
+	Exceptions       int      `json:"exceptions"`         // 异常数量（可能异常、投递失败、运输过久）
+	OnTime           int      `json:"on_time"`            // 准时签收数量
+	SLADelivered     int      `json:"sla_delivered"`      // 有时效标准的已签收数量
+	TransitDaysAvg   float64  `json:"transit_days_avg"`   // 平均运输天数（上网到签收）
+	TransitDaysP50   float64  `json:"transit_days_p50"`   // 运输天数 50 分位数
+	TransitDaysP90   float64  `json:"transit_days_p90"`   // 运输天数 90 分位数
+	TransitDaysP95   float64  `json:"transit_days_p95"`   // 运输天数 95 分位数
+	OnTimeRate       float64  `json:"on_time_rate"`       // 准时率（准时签收数量 / 有时效标准的已签收数量）
+	ExceptionRate    float64  `json:"exception_rate"`     // 异常率
+	FirstScanLagAvg  float64  `json:"first_scan_lag_avg"` // 平均首次扫描延迟天数（发货到上网）
+	transitDays      []float64
+	firstScanLagDays []float64
+}
+
+// AnalyticsReport 分析结果
+type AnalyticsReport struct {
+	GroupBy []AnalyticsDimension `json:"group_by"`
+	Groups  []AnalyticsGroup     `json:"groups"`
+}
+
+func dimensionValue(d AnalyticsDimension, track Track) string {
+	switch d {
+	case CourierDimension:
+		return track.CourierCode
+	case DestinationDimension:
+		return countryCode(track.Destination)
+	case LogisticsChannelDimension:
+		return track.LogisticsChannel
+	case MonthDimension:
+		if t := trackStartTime(track); !t.IsZero() {
+			return t.Format("2006-01")
+		}
+	}
+	return ""
+}
+
+// percentile 返回已排序数据的 p 分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Analyze 统计包裹的时效、准时率、异常率和首次扫描延迟
+func Analyze(reader TrackReader, options AnalyticsOptions) (report AnalyticsReport, err error) {
+	report.GroupBy = options.GroupBy
+	groups := make(map[string]*AnalyticsGroup)
+	var keys []string
+	for {
+		track, e := reader.Read()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			return report, e
+		}
+
+		values := make([]string, len(options.GroupBy))
+		for i, d := range options.GroupBy {
+			values[i] = dimensionValue(d, track)
+		}
+		key := strings.Join(values, "\x00")
+		g, ok := groups[key]
+		if !ok {
+			g = &AnalyticsGroup{Keys: values}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+
+		g.Total++
+		switch track.DeliveryStatus {
+		case StatusException, StatusUndelivered, StatusExpired:
+			g.Exceptions++
+		}
+		events := MergeTimeline(track, TimelineOptions{})
+		receivedAt := TrackMilestones(track).Received
+		if receivedAt.IsZero() && len(events) != 0 {
+			receivedAt = events[0].Time
+		}
+		if !receivedAt.IsZero() {
+			if shippedAt := firstTime(track.OrderCreateTime, track.CreatedAt); !shippedAt.IsZero() && !receivedAt.Before(shippedAt) {
+				g.firstScanLagDays = append(g.firstScanLagDays, receivedAt.Sub(shippedAt).Hours()/24)
+			}
+		}
+		if track.DeliveryStatus != StatusDelivered {
+			continue
+		}
+
+		g.Delivered++
+		days := -1.0
+		deliveredAt := firstTime(track.LatestCheckpointTime)
+		if deliveredAt.IsZero() && len(events) != 0 {
+			deliveredAt = events[len(events)-1].Time
+		}
+		if !receivedAt.IsZero() && !deliveredAt.IsZero() && !deliveredAt.Before(receivedAt) {
+			days = deliveredAt.Sub(receivedAt).Hours() / 24
+		} else if track.TransitTime > 0 {
+			days = float64(track.TransitTime)
+		}
+		if days < 0 {
+			continue
+		}
+		g.transitDays = append(g.transitDays, days)
+		if sla := options.SLA.For(track); sla > 0 {
+			g.SLADelivered++
+			if days <= float64(sla) {
+				g.OnTime++
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	report.Groups = make([]AnalyticsGroup, len(keys))
+	for i, key := range keys {
+		g := groups[key]
+		sort.Float64s(g.transitDays)
+		g.TransitDaysAvg = round2(average(g.transitDays))
+		g.TransitDaysP50 = round2(percentile(g.transitDays, 0.5))
+		g.TransitDaysP90 = round2(percentile(g.transitDays, 0.9))
+		g.TransitDaysP95 = round2(percentile(g.transitDays, 0.95))
+		g.FirstScanLagAvg = round2(average(g.firstScanLagDays))
+		if g.SLADelivered > 0 {
+			g.OnTimeRate = round2(float64(g.OnTime) / float64(g.SLADelivered))
+		}
+		g.ExceptionRate = round2(float64(g.Exceptions) / float64(g.Total))
+		report.Groups[i] = *g
+	}
+	return
+}
+
+// WriteCSV 输出为 CSV 格式
+func (r AnalyticsReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(r.GroupBy)+13)
+	for _, d := range r.GroupBy {
+		header = append(header, string(d))
+	}
+	header = append(header, "total", "delivered", "exceptions", "on_time", "sla_delivered", "transit_days_avg", "transit_days_p50", "transit_days_p90", "transit_days_p95", "on_time_rate", "exception_rate", "first_scan_lag_avg")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, g := range r.Groups {
+		row := append(append([]string(nil), g.Keys...),
+			strconv.Itoa(g.Total),
+			strconv.Itoa(g.Delivered),
+			strconv.Itoa(g.Exceptions),
+			strconv.Itoa(g.OnTime),
+			strconv.Itoa(g.SLADelivered),
+			f(g.TransitDaysAvg),
+			f(g.TransitDaysP50),
+			f(g.TransitDaysP90),
+			f(g.TransitDaysP95),
+			f(g.OnTimeRate),
+			f(g.ExceptionRate),
+			f(g.FirstScanLagAvg),
+		)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,81 @@
+package tracking51
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tracks := []Track{
+		{
+			TrackingNumber:       "A1",
+			CourierCode:          "usps",
+			Original:             "CN",
+			Destination:          "US",
+			DeliveryStatus:       StatusDelivered,
+			OrderCreateTime:      "2022-07-01 00:00:00",
+			OriginInfo:           Information{ReceivedDate: "2022-07-02 00:00:00"},
+			LatestCheckpointTime: "2022-07-07 00:00:00",
+		},
+		{
+			TrackingNumber:       "A2",
+			CourierCode:          "usps",
+			Original:             "CN",
+			Destination:          "US",
+			DeliveryStatus:       StatusDelivered,
+			OriginInfo:           Information{ReceivedDate: "2022-07-02 00:00:00"},
+			LatestCheckpointTime: "2022-07-17 00:00:00",
+		},
+		{
+			TrackingNumber: "A3",
+			CourierCode:    "usps",
+			Original:       "CN",
+			Destination:    "US",
+			DeliveryStatus: StatusException,
+			OriginInfo:     Information{ReceivedDate: "2022-07-03 00:00:00"},
+		},
+		{
+			TrackingNumber:  "B1",
+			CourierCode:     "dhl",
+			Original:        "CN",
+			Destination:     "GB",
+			DeliveryStatus:  StatusDelivered,
+			OrderCreateTime: "2022-08-01 00:00:00",
+			TransitTime:     4,
+		},
+	}
+	report, err := Analyze(NewSliceTrackReader(tracks), AnalyticsOptions{
+		GroupBy: []AnalyticsDimension{CourierDimension, MonthDimension},
+		SLA:     SLA{Default: 3, Lanes: map[string]int{"usps:CN-US": 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(report.Groups))
+	}
+
+	dhl, usps := report.Groups[0], report.Groups[1]
+	if strings.Join(dhl.Keys, ",") != "dhl,2022-08" || dhl.Delivered != 1 || dhl.OnTime != 0 || dhl.OnTimeRate != 0 || dhl.TransitDaysP50 != 4 {
+		t.Errorf("unexpected dhl group: %#v", dhl)
+	}
+	if strings.Join(usps.Keys, ",") != "usps,2022-07" || usps.Total != 3 || usps.Delivered != 2 || usps.Exceptions != 1 {
+		t.Errorf("unexpected usps group: %#v", usps)
+	}
+	if usps.OnTime != 1 || usps.OnTimeRate != 0.5 || usps.ExceptionRate != 0.33 {
+		t.Errorf("unexpected usps rates: %#v", usps)
+	}
+	if usps.TransitDaysAvg != 10 || usps.TransitDaysP50 != 5 || usps.TransitDaysP95 != 15 || usps.FirstScanLagAvg != 1 {
+		t.Errorf("unexpected usps transit days: %#v", usps)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = report.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "courier,month,total,") || !strings.HasPrefix(lines[2], "usps,2022-07,3,2,1,1,2,10,5,15,15,0.5,0.33,1") {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+}
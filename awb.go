@@ -0,0 +1,26 @@
+package tracking51
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 航空运单号（主运单号）
+
+// NormalizeAWBNumber 去掉运单号中的空格和横线，并校验格式（3 位航空公司前缀 + 8 位序号，序号的最后一位为前 7 位除以 7 的余数）
+func NormalizeAWBNumber(s string) (string, error) {
+	s = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(s))
+	if len(s) != 11 {
+		return s, messageError("awb_number_invalid")
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return s, messageError("awb_number_invalid")
+		}
+	}
+	serial, _ := strconv.Atoi(s[3:10])
+	if serial%7 != int(s[10]-'0') {
+		return s, messageError("awb_number_check_digit_invalid")
+	}
+	return s, nil
+}
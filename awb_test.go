@@ -0,0 +1,25 @@
+package tracking51
+
+import (
+	"testing"
+)
+
+func TestNormalizeAWBNumber(t *testing.T) {
+	tests := []struct {
+		number   string
+		expected string
+		valid    bool
+	}{
+		{"784-12345675", "78412345675", true},
+		{"784 1234 5675", "78412345675", true},
+		{"784-12345676", "78412345676", false},
+		{"784-1234567", "7841234567", false},
+		{"78A-12345675", "78A12345675", false},
+	}
+	for _, test := range tests {
+		number, err := NormalizeAWBNumber(test.number)
+		if number != test.expected || (err == nil) != test.valid {
+			t.Errorf("%s: expected %s, %v, got %s, %v", test.number, test.expected, test.valid, number, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tracking51
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"sort"
+	"strings"
+)
+
+// 批量请求数据验证
+
+// BatchItemError 批量请求中一项数据的验证错误
+type BatchItemError struct {
+	Index          int               `json:"index"`           // 在请求数据中的位置（从 0 开始）
+	TrackingNumber string            `json:"tracking_number"` // 包裹物流单号
+	Fields         map[string]string `json:"fields"`          // 字段 => 错误信息，不属于某个字段的错误使用空字符串作为键
+	err            error
+	lang           string // 错误信息的语言（通过 TranslateError 翻译后设置）
+}
+
+func (e BatchItemError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	messages := make([]string, len(keys))
+	for i, k := range keys {
+		if k == "" {
+			messages[i] = e.Fields[k]
+		} else {
+			messages[i] = k + ": " + e.Fields[k]
+		}
+	}
+	params := map[string]interface{}{"index": e.Index + 1, "messages": strings.Join(messages, "; ")}
+	if e.TrackingNumber == "" {
+		return languageErrorf(e.lang, "batch_item", params).Error()
+	}
+	params["tracking_number"] = e.TrackingNumber
+	return languageErrorf(e.lang, "batch_item_tracking_number", params).Error()
+}
+
+// BatchError 批量请求中所有没有通过验证的数据
+type BatchError struct {
+	Items []BatchItemError `json:"items"`
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		messages[i] = item.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Indexes 返回没有通过验证的数据的位置
+func (e *BatchError) Indexes() []int {
+	indexes := make([]int, len(e.Items))
+	for i, item := range e.Items {
+		indexes[i] = item.Index
+	}
+	return indexes
+}
+
+func newBatchItemError(index int, trackingNumber string, err error) BatchItemError {
+	item := BatchItemError{Index: index, TrackingNumber: trackingNumber, Fields: make(map[string]string), err: err}
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		for field, fieldErr := range errs {
+			item.Fields[field] = fieldErr.Error()
+		}
+	} else {
+		item.Fields[""] = err.Error()
+	}
+	return item
+}
+
+// 添加一项错误，e 为 nil 时创建新的 BatchError
+func (e *BatchError) add(index int, trackingNumber string, err error) *BatchError {
+	if e == nil {
+		e = &BatchError{}
+	}
+	e.Items = append(e.Items, newBatchItemError(index, trackingNumber, err))
+	return e
+}
+
+// 检查请求数据的数量，max 为 0 表示不限制数量
+func validateBatchSize(n, max int) error {
+	if n == 0 {
+		return messageError("batch_empty")
+	} else if max > 0 && n > max {
+		return messageErrorf("batch_too_many", map[string]interface{}{"max": max})
+	}
+	return nil
+}
+
+func validateTrackingNumberCourierCode(m trackingNumberCourierCode) error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.TrackingNumber, validation.Required.ErrorObject(messageError("tracking_number_required"))),
+		validation.Field(&m.CourierCode, validation.Required.ErrorObject(messageError("courier_code_required"))),
+	)
+}
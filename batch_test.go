@@ -0,0 +1,47 @@
+package tracking51
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBatchError(t *testing.T) {
+	req := DeleteTrackRequests{
+		{TrackingNumber: "A", CourierCode: "usps"},
+		{TrackingNumber: "B"},
+		{TrackingNumber: "C", CourierCode: "usps"},
+		{CourierCode: "usps"},
+	}
+	err := req.Validate()
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected *BatchError, got %v", err)
+	}
+	if indexes := batchErr.Indexes(); len(indexes) != 2 || indexes[0] != 1 || indexes[1] != 3 {
+		t.Errorf("unexpected indexes: %v", indexes)
+	}
+	if batchErr.Items[0].Fields["courier_code"] != "物流商简码不能为空" || batchErr.Items[1].Fields["tracking_number"] != "包裹物流单号不能为空" {
+		t.Errorf("unexpected fields: %#v", batchErr.Items)
+	}
+	if s := err.Error(); !strings.Contains(s, "第 2 个单号 B：courier_code: 物流商简码不能为空") || !strings.Contains(s, "第 4 个：tracking_number:") {
+		t.Errorf("unexpected error message: %s", s)
+	}
+
+	valid, invalid := req.Partition()
+	if len(valid) != 2 || valid[0].TrackingNumber != "A" || valid[1].TrackingNumber != "C" || len(invalid.Items) != 2 {
+		t.Errorf("unexpected partition: %#v, %#v", valid, invalid)
+	}
+	if err = valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err = make(StopUpdateTrackRequests, 41).Validate(); err == nil || errors.As(err, &batchErr) {
+		t.Errorf("expected size error, got %v", err)
+	}
+
+	transit := TransitTimeRequests{{CourierCode: "usps", OriginalCode: "CN", DestinationCode: "XX"}}
+	if _, invalid = transit.Partition(); invalid == nil || invalid.Items[0].Fields["destination_code"] != "无效的目的国二字简码" {
+		t.Errorf("unexpected transit time partition: %#v", invalid)
+	}
+}
@@ -0,0 +1,300 @@
+package tracking51
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// 批量请求聚合
+//
+// 多个协程各自提交单个包裹的添加、手动更新和删除请求时，Batcher 将短时间内提交的请求合并为一次最多 40 个包裹的批量请求，
+// 并将每个包裹的结果返回给对应的提交者。
+//
+// 同一批次中相同包裹（单号和物流商相同）的相同请求只发送一次，所有提交者得到相同的结果；
+// 请求数据不同时（比如添加单号时的订单号不同，比较时忽略首尾空格以及国家简码和邮箱的大小写），后提交的请求返回 ErrBatchConflict，
+// 需要在之前的请求完成后重新提交。
+
+var (
+	ErrBatcherClosed = newMessageError("batcher_closed")
+	ErrBatchNoResult = newMessageError("batch_no_result")
+	ErrBatchConflict = newMessageError("batch_conflict")
+)
+
+// BatchResult 单个包裹在批量请求中的结果
+type BatchResult struct {
+	Success      bool   `json:"success"`       // 是否成功
+	ErrorCode    int    `json:"error_code"`    // 失败时接口返回的错误代码
+	ErrorMessage string `json:"error_message"` // 失败时接口返回的错误信息
+	Err          error  `json:"-"`             // 数据验证错误、请求错误或者接口返回的该包裹的错误（*APIError），此时 Success 为 false
+}
+
+// BatcherOptions 批量请求聚合选项
+type BatcherOptions struct {
+	FlushInterval time.Duration // 第一个请求提交后等待多长时间发送批量请求，默认为 100 毫秒
+	MaxBatchSize  int           // 每次批量请求的最大包裹数量，达到该数量时立即发送，默认（最大）为 40
+	Language      string        // 数据验证错误的语言（可以使用 Tracking51.Language()），默认为中文
+}
+
+type batchCall struct {
+	request    interface{}
+	normalized interface{}        // 用于判断请求数据是否相同
+	results    []chan BatchResult // 相同包裹的所有提交者
+}
+
+// 发送批量请求，返回包裹 => 结果
+type batchSendFunc func(requests []interface{}) (map[string]BatchResult, error)
+
+type batchQueue struct {
+	mu      sync.Mutex
+	send    batchSendFunc
+	options BatcherOptions
+	keys    []string
+	calls   map[string]*batchCall
+	timer   *time.Timer
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+func newBatchQueue(send batchSendFunc, options BatcherOptions) *batchQueue {
+	return &batchQueue{send: send, options: options, calls: make(map[string]*batchCall)}
+}
+
+func (q *batchQueue) submit(key string, request, normalized interface{}) <-chan BatchResult {
+	ch := make(chan BatchResult, 1)
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		ch <- BatchResult{Err: TranslateError(ErrBatcherClosed, q.options.Language)}
+		return ch
+	}
+	if call, ok := q.calls[key]; ok {
+		if call.normalized != normalized {
+			q.mu.Unlock()
+			ch <- BatchResult{Err: TranslateError(ErrBatchConflict, q.options.Language)}
+			return ch
+		}
+		call.results = append(call.results, ch)
+		q.mu.Unlock()
+		return ch
+	}
+
+	q.keys = append(q.keys, key)
+	q.calls[key] = &batchCall{request: request, normalized: normalized, results: []chan BatchResult{ch}}
+	if len(q.keys) >= q.options.MaxBatchSize {
+		keys, calls := q.take()
+		q.mu.Unlock()
+		go q.do(keys, calls)
+		return ch
+	}
+	if q.timer == nil {
+		q.timer = time.AfterFunc(q.options.FlushInterval, q.flush)
+	}
+	q.mu.Unlock()
+	return ch
+}
+
+// 取出等待中的请求（需要持有锁）
+func (q *batchQueue) take() ([]string, map[string]*batchCall) {
+	keys, calls := q.keys, q.calls
+	q.keys = nil
+	q.calls = make(map[string]*batchCall)
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	if len(keys) != 0 {
+		q.wg.Add(1)
+	}
+	return keys, calls
+}
+
+func (q *batchQueue) flush() {
+	q.mu.Lock()
+	keys, calls := q.take()
+	q.mu.Unlock()
+	q.do(keys, calls)
+}
+
+func (q *batchQueue) do(keys []string, calls map[string]*batchCall) {
+	if len(keys) == 0 {
+		return
+	}
+	defer q.wg.Done()
+
+	requests := make([]interface{}, len(keys))
+	for i, key := range keys {
+		requests[i] = calls[key].request
+	}
+	results, err := q.send(requests)
+	for _, key := range keys {
+		result, ok := results[key]
+		if err != nil {
+			result = BatchResult{Err: err}
+		} else if !ok {
+			result = BatchResult{Err: TranslateError(ErrBatchNoResult, q.options.Language)}
+		}
+		for _, ch := range calls[key].results {
+			ch <- result
+		}
+	}
+}
+
+// 发送所有等待中的请求，并等待所有请求完成，之后提交的请求返回 ErrBatcherClosed
+func (q *batchQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	keys, calls := q.take()
+	q.mu.Unlock()
+	q.do(keys, calls)
+	q.wg.Wait()
+}
+
+// Batcher 批量请求聚合器，可以在多个协程中使用
+type Batcher struct {
+	tracking TrackingService
+	language string
+	create   *batchQueue
+	refresh  *batchQueue
+	delete   *batchQueue
+}
+
+func NewBatcher(tracking TrackingService, options BatcherOptions) *Batcher {
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 100 * time.Millisecond
+	}
+	if options.MaxBatchSize <= 0 || options.MaxBatchSize > 40 {
+		options.MaxBatchSize = 40
+	}
+	b := &Batcher{tracking: tracking, language: options.Language}
+	b.create = newBatchQueue(b.sendCreate, options)
+	b.refresh = newBatchQueue(b.sendRefresh, options)
+	b.delete = newBatchQueue(b.sendDelete, options)
+	return b
+}
+
+// 用于比较的添加单号请求（去掉首尾空格，国家简码转为大写，邮箱转为小写）
+func normalizeCreateTrackRequest(req CreateTrackRequest) CreateTrackRequest {
+	return CreateTrackRequest{
+		TrackingNumber:          strings.TrimSpace(req.TrackingNumber),
+		CourierCode:             strings.TrimSpace(req.CourierCode),
+		OrderNumber:             strings.TrimSpace(req.OrderNumber),
+		Title:                   strings.TrimSpace(req.Title),
+		DestinationCode:         strings.ToUpper(strings.TrimSpace(req.DestinationCode)),
+		LogisticsChannel:        strings.TrimSpace(req.LogisticsChannel),
+		Note:                    strings.TrimSpace(req.Note),
+		CustomerName:            strings.TrimSpace(req.CustomerName),
+		CustomerEmail:           strings.ToLower(strings.TrimSpace(req.CustomerEmail)),
+		CustomerPhone:           strings.TrimSpace(req.CustomerPhone),
+		ShippingDate:            strings.TrimSpace(req.ShippingDate),
+		TrackingShippingDate:    strings.TrimSpace(req.TrackingShippingDate),
+		TrackingPostalCode:      strings.TrimSpace(req.TrackingPostalCode),
+		TrackingDestinationCode: strings.ToUpper(strings.TrimSpace(req.TrackingDestinationCode)),
+		TrackingCourierAccount:  strings.TrimSpace(req.TrackingCourierAccount),
+	}
+}
+
+// 接口返回的单个包裹的失败结果
+func failedBatchResult(code int, message, lang string) BatchResult {
+	result := BatchResult{ErrorCode: code, ErrorMessage: message}
+	if result.Err = errorWrap(code, message, lang); result.Err == nil {
+		// 错误代码为成功时仍然视为失败
+		result.Err = &APIError{Code: code, Message: message}
+	}
+	return result
+}
+
+// 数据验证失败时直接返回结果
+func invalidBatchResult(err error) <-chan BatchResult {
+	ch := make(chan BatchResult, 1)
+	ch <- BatchResult{Err: err}
+	return ch
+}
+
+// Create 提交添加单号请求，结果通过返回的 channel 获取
+func (b *Batcher) Create(req CreateTrackRequest) <-chan BatchResult {
+	if err := validate(req, b.language); err != nil {
+		return invalidBatchResult(err)
+	}
+	return b.create.submit(trackKey(req.TrackingNumber, req.CourierCode), req, normalizeCreateTrackRequest(req))
+}
+
+func (b *Batcher) sendCreate(requests []interface{}) (map[string]BatchResult, error) {
+	req := make(CreateTrackRequests, len(requests))
+	for i, r := range requests {
+		req[i] = r.(CreateTrackRequest)
+	}
+	success, errorItems, err := b.tracking.BatchCreate(req)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]BatchResult, len(requests))
+	for _, item := range success {
+		results[trackKey(item.TrackingNumber, item.CourierCode)] = BatchResult{Success: true}
+	}
+	for _, item := range errorItems {
+		results[trackKey(item.TrackingNumber, item.CourierCode)] = failedBatchResult(item.ErrorCode, item.ErrorMessage, b.language)
+	}
+	return results, nil
+}
+
+// Refresh 提交手动更新请求，结果通过返回的 channel 获取
+func (b *Batcher) Refresh(req RefreshTrackRequest) <-chan BatchResult {
+	if err := validate(req, b.language); err != nil {
+		return invalidBatchResult(err)
+	}
+	return b.refresh.submit(trackKey(req.TrackingNumber, req.CourierCode), req, req)
+}
+
+func (b *Batcher) sendRefresh(requests []interface{}) (map[string]BatchResult, error) {
+	req := make(RefreshTrackRequests, len(requests))
+	for i, r := range requests {
+		req[i] = r.(RefreshTrackRequest)
+	}
+	success, errorItems, err := b.tracking.Refresh(req)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]BatchResult, len(requests))
+	for _, item := range success {
+		results[trackKey(item.TrackingNumber, item.CourierCode)] = BatchResult{Success: true}
+	}
+	for _, item := range errorItems {
+		results[trackKey(item.TrackingNumber, item.CourierCode)] = failedBatchResult(item.ErrorCode, item.ErrorMessage, b.language)
+	}
+	return results, nil
+}
+
+// Delete 提交删除单号请求，结果通过返回的 channel 获取
+func (b *Batcher) Delete(req DeleteTrackRequest) <-chan BatchResult {
+	if err := validateTrackingNumberCourierCode(trackingNumberCourierCode(req)); err != nil {
+		return invalidBatchResult(TranslateError(err, b.language))
+	}
+	return b.delete.submit(trackKey(req.TrackingNumber, req.CourierCode), req, req)
+}
+
+func (b *Batcher) sendDelete(requests []interface{}) (map[string]BatchResult, error) {
+	req := make(DeleteTrackRequests, len(requests))
+	for i, r := range requests {
+		req[i] = r.(DeleteTrackRequest)
+	}
+	success, errorItems, err := b.tracking.Delete(req)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]BatchResult, len(requests))
+	for _, item := range success {
+		results[trackKey(item.TrackingNumber, item.CourierCode)] = BatchResult{Success: true}
+	}
+	for _, item := range errorItems {
+		results[trackKey(item.TrackingNumber, item.CourierCode)] = failedBatchResult(item.ErrorCode, item.ErrorMessage, b.language)
+	}
+	return results, nil
+}
+
+// Close 立即发送所有等待中的请求并等待完成，关闭后提交的请求返回 ErrBatcherClosed
+func (b *Batcher) Close() {
+	b.create.close()
+	b.refresh.close()
+	b.delete.close()
+}
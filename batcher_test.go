@@ -0,0 +1,112 @@
+package tracking51
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req []trackingNumberCourierCode
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &req)
+		mu.Lock()
+		sizes = append(sizes, len(req))
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/manualupdate":
+			var success []trackingNumberCourierCode
+			var errorItems []RefreshResultError
+			for _, item := range req {
+				if item.TrackingNumber == "R0" {
+					errorItems = append(errorItems, RefreshResultError{trackingNumberCourierCode: item, ErrorCode: 4110, ErrorMessage: "The value of tracking_number is invalid."})
+				} else {
+					success = append(success, item)
+				}
+			}
+			writeTestResponse(w, map[string]interface{}{"success": success, "error": errorItems})
+		case "/create":
+			var success []trackingNumberCourierCode
+			var errorItems []CreateResult
+			for _, item := range req {
+				if item.TrackingNumber == "C2" {
+					errorItems = append(errorItems, CreateResult{TrackingNumber: item.TrackingNumber, CourierCode: item.CourierCode, ErrorCode: TrackingNumberIsExistsError, ErrorMessage: "Tracking No. already exists."})
+				} else {
+					success = append(success, item)
+				}
+			}
+			writeTestResponse(w, map[string]interface{}{"success": success, "error": errorItems})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	batcher := NewBatcher(c.Services.Tracking, BatcherOptions{FlushInterval: 50 * time.Millisecond})
+	var wg sync.WaitGroup
+	results := make([]BatchResult, 45)
+	for i := 0; i < 45; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = <-batcher.Refresh(RefreshTrackRequest{TrackingNumber: fmt.Sprintf("R%d", i), CourierCode: "usps"})
+		}(i)
+	}
+	wg.Wait()
+	if len(sizes) != 2 || sizes[0]+sizes[1] != 45 || (sizes[0] != 40 && sizes[1] != 40) {
+		t.Errorf("expected batches of 40 and 5, got %v", sizes)
+	}
+	for i, result := range results {
+		if i == 0 {
+			if result.Success || result.ErrorCode != 4110 {
+				t.Errorf("unexpected result: %#v", result)
+			}
+		} else if !result.Success || result.Err != nil {
+			t.Errorf("%d: unexpected result: %#v", i, result)
+		}
+	}
+
+	if result := <-batcher.Refresh(RefreshTrackRequest{TrackingNumber: "R1"}); result.Err == nil {
+		t.Error("expected validation error")
+	}
+
+	// 相同的包裹只提交一次
+	sizes = nil
+	first := batcher.Create(CreateTrackRequest{TrackingNumber: "C1", CourierCode: "usps"})
+	second := batcher.Create(CreateTrackRequest{TrackingNumber: "C1", CourierCode: "usps"})
+	// 相同的包裹但是请求数据不同
+	conflict := batcher.Create(CreateTrackRequest{TrackingNumber: "C1", CourierCode: "usps", OrderNumber: "O1"})
+	// 国家简码大小写不同视为相同的请求
+	exists := batcher.Create(CreateTrackRequest{TrackingNumber: "C2", CourierCode: "usps", DestinationCode: "us"})
+	same := batcher.Create(CreateTrackRequest{TrackingNumber: "C2", CourierCode: "usps", DestinationCode: "US"})
+	batcher.Close()
+	if r1, r2 := <-first, <-second; !r1.Success || !r2.Success {
+		t.Errorf("unexpected results: %#v, %#v", r1, r2)
+	}
+	if result := <-conflict; !errors.Is(result.Err, ErrBatchConflict) {
+		t.Errorf("expected ErrBatchConflict, got %#v", result)
+	}
+	r1, r2 := <-exists, <-same
+	var apiErr *APIError
+	if r1.Success || r1.ErrorCode != TrackingNumberIsExistsError || !errors.As(r1.Err, &apiErr) || apiErr.ID != "tracking_number_exists" {
+		t.Errorf("expected api error, got %#v", r1)
+	}
+	if r2.ErrorCode != r1.ErrorCode {
+		t.Errorf("expected the same result, got %#v", r2)
+	}
+	if len(sizes) != 1 || sizes[0] != 2 {
+		t.Errorf("expected one batch with two items, got %v", sizes)
+	}
+
+	if result := <-batcher.Delete(DeleteTrackRequest{TrackingNumber: "D1", CourierCode: "usps"}); !errors.Is(result.Err, ErrBatcherClosed) {
+		t.Errorf("expected ErrBatcherClosed, got %v", result.Err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -41,7 +42,7 @@ const (
 	StatusTransit      = "transit"      // 运输中
 	StatusPickup       = "pickup"       // 到达待取
 	StatusDelivered    = "delivered"    // 成功签收
-	StatusExpired      = "notfound"     // 运输过久
+	StatusExpired      = "expired"      // 运输过久
 	StatusUndelivered  = "undelivered"  // 投递失败
 	StatusException    = "exception"    // 可能异常
 	StatusInfoReceived = "inforeceived" // 待上网
@@ -60,6 +61,7 @@ const (
 type Tracking51 struct {
 	latestRequestTime time.Time      // 最后请求时间（在传入了 IntervalTime 后，该值用于控制接口调取频率处理，未传入的话不起作用）
 	config            *config.Config // 配置
+	logger            *log.Logger    // 日志记录器
 	httpClient        *resty.Client  // Resty Client
 	Services          services       // API Services
 }
@@ -68,6 +70,7 @@ func NewTracking51(config config.Config) *Tracking51 {
 	logger := log.New(os.Stdout, "[ 51Tracking ] ", log.LstdFlags|log.Llongfile)
 	client := &Tracking51{
 		config: &config,
+		logger: logger,
 	}
 
 	baseURL := "https://api.51tracking.com/v3/trackings"
@@ -95,7 +98,7 @@ func NewTracking51(config config.Config) *Tracking51 {
 			}{}
 			if err = json.Unmarshal(response.Body(), &r); err == nil {
 				if r.Code != Success {
-					err = ErrorWrap(r.Code, r.Message)
+					err = errorWrap(r.Code, r.Message, config.Language)
 				}
 			} else {
 				logger.Printf("JSON Unmarshal error: %s", err.Error())
@@ -163,10 +166,21 @@ func NewTracking51(config config.Config) *Tracking51 {
 		Account:  (accountService)(xService),
 		Courier:  (courierService)(xService),
 		Tracking: (trackingService)(xService),
+		Realtime: newRealtimeService(xService),
 	}
 	return client
 }
 
+// Logger 返回客户端使用的日志记录器（可以传入 SyncOptions.Logger 等选项）
+func (t *Tracking51) Logger() *log.Logger {
+	return t.logger
+}
+
+// Language 返回配置的错误信息语言（可以传入 ETAOptions.Language 等选项）
+func (t *Tracking51) Language() string {
+	return t.config.Language
+}
+
 // SetDebug 设置是否开启调试模式
 func (t *Tracking51) SetDebug(v bool) *Tracking51 {
 	t.config.Debug = v
@@ -180,45 +194,33 @@ type NormalResponse struct {
 	Data    interface{} `json:"data"`
 }
 
-// ErrorWrap 错误包装
+// ErrorWrap 错误包装（中文错误信息），返回的错误为 *APIError
 func ErrorWrap(code int, message string) error {
-	if code == Success || code == NoContent {
-		return nil
-	}
+	return errorWrap(code, message, ChineseLanguage)
+}
+
+// 51Tracking 返回的时间格式
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"20060102",
+}
 
-	switch code {
-	case PaymentRequiredError:
-		message = "API 服务只提供给付费账户，请付费购买单号以解锁 API 服务"
-	case BadRequestError:
-		message = "请求类型错误"
-	case UnauthorizedError:
-		message = "授权失败或没有权限，请检查并确保你 API Key 正确无误"
-	case NotFoundError:
-		message = "请求的资源不存在"
-	case TimeOutError:
-		message = "请求超时"
-	case RequestParametersTooLongError:
-		message = "请求参数长度超过限制"
-	case RequestParametersFormatError:
-		message = "请求参数格式不合要求"
-	case RequestParametersExceededLimitError:
-		message = "请求参数数量超过限制"
-	case LostRequestParametersOrParseError:
-		message = "缺少请求参数或者请求参数无法解析"
-	case ParametersInvalidError:
-		message = "部分必填参数为空"
-	case CourierCodeInvalidError:
-		message = "物流商简码无法识别或者不支持该物流商"
-	case TrackingNumberIsExistsError:
-		message = "跟踪单号已存在，无需再次创建"
-	case TrackingNumberIsNotExistsError:
-		message = "跟踪单号不存在"
-	case TooManyRequestsError:
-		message = "API 请求频率次数限制，请稍后再试"
-	case InternalError:
-		message = "系统错误"
+// parseTime 解析 51Tracking 返回的时间字符串
+func parseTime(s string) (t time.Time, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
 	}
-	return fmt.Errorf("%d: %s", code, message)
+	for _, layout := range timeLayouts {
+		if v, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return v, true
+		}
+	}
+	return
 }
 
 // change to url.values
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hiscaler/51tracking-go/config"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 )
@@ -25,3 +27,32 @@ func TestMain(m *testing.M) {
 	client.SetDebug(c.Debug)
 	m.Run()
 }
+
+// newTestClient 返回请求发送到本地测试服务的客户端
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Tracking51 {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	c := NewTracking51(config.Config{AppKey: "test"})
+	c.httpClient.SetBaseURL(ts.URL)
+	return c
+}
+
+// writeTestResponse 输出 51Tracking 格式的响应数据
+func writeTestResponse(w http.ResponseWriter, data interface{}) {
+	b, _ := json.Marshal(NormalResponse{Code: Success, Message: "Success", Data: data})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func TestDeliveryStatusValues(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, status := range DeliveryStatuses() {
+		if seen[status.Code] {
+			t.Errorf("duplicate delivery status value: %s", status.Code)
+		}
+		seen[status.Code] = true
+	}
+	if StatusExpired != "expired" {
+		t.Errorf("unexpected StatusExpired value: %s", StatusExpired)
+	}
+}
@@ -0,0 +1,110 @@
+// 51tracking 命令行工具
+//
+// 模拟 51Tracking 推送：
+//
+//	51tracking simulate -url http://localhost:8080/webhook -email you@example.com -number LX123456789CN -courier china-post -status transit
+//	51tracking simulate -url http://localhost:8080/webhook -email you@example.com -timeline timeline.json
+//
+// timeline.json 格式：
+//
+//	{
+//	  "track": {"tracking_number": "LX123456789CN", "courier_code": "china-post"},
+//	  "steps": [
+//	    {"delay": "0s", "delivery_status": "transit", "checkpoint": {"checkpoint_date": "2022-07-01 10:00:00", "tracking_detail": "Posting", "location": "Shenzhen"}},
+//	    {"delay": "5s", "delivery_status": "delivered", "checkpoint": {"checkpoint_date": "2022-07-08 15:00:00", "tracking_detail": "Delivered", "location": "New York"}}
+//	  ]
+//	}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	tracking51 "github.com/hiscaler/51tracking-go"
+	"os"
+	"time"
+)
+
+type timelineStep struct {
+	tracking51.WebhookTimelineStep
+	Delay string `json:"delay"` // 等待时间（例子：5s, 1m）
+}
+
+type timelineFile struct {
+	Track tracking51.Track `json:"track"`
+	Steps []timelineStep   `json:"steps"`
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: 51tracking <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  simulate    发送模拟的 51Tracking 推送")
+}
+
+func simulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	url := fs.String("url", "", "推送接收地址")
+	email := fs.String("email", "", "51Tracking 用户邮箱，用于计算推送签名")
+	number := fs.String("number", "", "包裹物流单号")
+	courier := fs.String("courier", "", "物流商简码")
+	status := fs.String("status", tracking51.StatusTransit, "包裹状态")
+	timeline := fs.String("timeline", "", "状态变化文件（JSON 格式），指定后忽略 number、courier 和 status 参数")
+	fs.Parse(args)
+	if *url == "" || *email == "" {
+		fs.Usage()
+		return fmt.Errorf("url 和 email 不能为空")
+	}
+
+	var file timelineFile
+	if *timeline != "" {
+		b, err := os.ReadFile(*timeline)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(b, &file); err != nil {
+			return err
+		}
+	} else {
+		if *number == "" || *courier == "" {
+			fs.Usage()
+			return fmt.Errorf("number 和 courier 不能为空")
+		}
+		file.Track = tracking51.Track{TrackingNumber: *number, CourierCode: *courier}
+		file.Steps = []timelineStep{{WebhookTimelineStep: tracking51.WebhookTimelineStep{DeliveryStatus: *status}}}
+	}
+
+	steps := make([]tracking51.WebhookTimelineStep, len(file.Steps))
+	for i, step := range file.Steps {
+		steps[i] = step.WebhookTimelineStep
+		if step.Delay != "" {
+			d, err := time.ParseDuration(step.Delay)
+			if err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+			steps[i].Delay = d
+		}
+	}
+	return tracking51.ReplayWebhooks(context.Background(), *url, *email, file.Track, steps)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "simulate":
+		err = simulate(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
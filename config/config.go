@@ -1,9 +1,12 @@
 package config
 
 type Config struct {
-	Debug        bool   // 是否为调试模式（调试模式下会输出 HTTP 请求和返回数据）
-	Sandbox      bool   // 是否为沙箱测试环境
-	Version      string // API 版本（当前固定为 V3）
-	AppKey       string // App Key
-	IntervalTime int64  // 当前请求与上次请求间隔的时间（单位为毫秒），默认为零，表示没有间隔，大于 0 表示实际间隔的毫秒数
+	Debug                bool   // 是否为调试模式（调试模式下会输出 HTTP 请求和返回数据）
+	Sandbox              bool   // 是否为沙箱测试环境
+	Version              string // API 版本（当前固定为 V3）
+	AppKey               string // App Key
+	Language             string // 错误信息的语言（cn, en），默认为 cn
+	IntervalTime         int64  // 当前请求与上次请求间隔的时间（单位为毫秒），默认为零，表示没有间隔，大于 0 表示实际间隔的毫秒数
+	RealtimeIntervalTime int64  // 实时查询请求的间隔时间（单位为毫秒），默认为 1000
+	RealtimeQuota        int    // 实时查询的次数额度（只在内存中计数，重启后重新计数），默认为零，表示不限制
 }
@@ -0,0 +1,482 @@
+package tracking51
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 物流商纠正
+//
+// 使用错误的物流商添加单号后包裹会一直处于“查询不到”状态。纠正流程为：查找添加超过指定天数仍查询不到的包裹，
+// 根据单号格式和物流商列表生成候选物流商，依次修改为候选物流商并在等待一段时间后检查包裹状态，
+// 包裹状态不再是“查询不到”时纠正成功，所有候选物流商都失败时恢复为原来的物流商。
+//
+// 修改物流商后 51Tracking 需要重新查询，包裹状态为“查询中”或者查询结果中没有该包裹时会继续等待（最多 PendingTimeout）。
+// 包裹的物流商已被修改为其他物流商时放弃纠正，不再修改物流商。
+// 设置 Store 后纠正进度会被持久化，重启后可以继续检查等待中的尝试，并在所有候选物流商都失败时恢复为原来的物流商。
+
+// CourierCorrectionResult 一次尝试的结果
+type CourierCorrectionResult string
+
+const (
+	CourierCorrectionPending      CourierCorrectionResult = "pending"       // 已修改物流商，等待检查包裹状态
+	CourierCorrectionSucceeded    CourierCorrectionResult = "succeeded"     // 包裹状态不再是“查询不到”，纠正成功
+	CourierCorrectionFailed       CourierCorrectionResult = "failed"        // 包裹仍然查询不到，继续尝试下一个候选物流商
+	CourierCorrectionUpdateFailed CourierCorrectionResult = "update_failed" // 修改物流商失败
+	CourierCorrectionRolledBack   CourierCorrectionResult = "rolled_back"   // 所有候选物流商都失败，已恢复为原来的物流商
+	CourierCorrectionAbandoned    CourierCorrectionResult = "abandoned"     // 包裹的物流商已被修改为其他物流商（比如手动修改），放弃纠正
+)
+
+// CourierCorrectionAttempt 一次尝试修改物流商的记录
+type CourierCorrectionAttempt struct {
+	TrackingNumber      string                  `json:"tracking_number"`       // 包裹物流单号
+	OriginalCourierCode string                  `json:"original_courier_code"` // 原来的物流商简码
+	CourierCode         string                  `json:"courier_code"`          // 尝试的物流商简码（恢复时为原来的物流商简码）
+	DeliveryStatus      string                  `json:"delivery_status"`       // 检查时的包裹状态
+	Result              CourierCorrectionResult `json:"result"`                // 结果
+	Error               string                  `json:"error"`                 // 错误信息
+	AttemptedAt         time.Time               `json:"attempted_at"`          // 修改物流商的时间
+	CheckedAt           time.Time               `json:"checked_at"`            // 检查包裹状态的时间
+}
+
+// CourierCorrectionOptions 物流商纠正选项
+type CourierCorrectionOptions struct {
+	NotFoundDays   int                                    // 添加超过多少天仍查询不到的包裹需要纠正，默认为 7
+	CheckDelay     time.Duration                          // 修改物流商后等待多长时间检查包裹状态，默认为 1 小时
+	PendingTimeout time.Duration                          // 修改物流商后包裹状态为“查询中”时最多等待多长时间，超过后视为失败，默认为 24 小时
+	Store          CourierCorrectionStore                 // 纠正进度的存储（可选），不设置时只保存在内存中
+	MaxCandidates  int                                    // 每个包裹最多尝试的候选物流商数量，默认为 3
+	Interval       time.Duration                          // Run 的执行间隔，默认为 10 分钟
+	Detector       *CourierDetector                       // 单号格式识别器，默认为 DefaultCourierDetector
+	OnAttempt      func(attempt CourierCorrectionAttempt) // 尝试记录有变化时的回调
+	Logger         *log.Logger                            // 日志记录器（可以使用 Tracking51.Logger()），默认输出到标准输出
+	Language       string                                 // 错误信息的语言（可以使用 Tracking51.Language()），默认为中文
+}
+
+type courierCorrection struct {
+	trackingNumber      string
+	originalCourierCode string
+	courierCode         string   // 当前的物流商简码
+	candidates          []string // 还没有尝试的候选物流商
+	attempt             int      // 当前尝试在 attempts 中的位置
+	done                bool
+}
+
+// CourierCorrectionProgress 一个包裹的纠正进度
+type CourierCorrectionProgress struct {
+	TrackingNumber      string   `json:"tracking_number"`       // 包裹物流单号
+	OriginalCourierCode string   `json:"original_courier_code"` // 原来的物流商简码
+	CourierCode         string   `json:"courier_code"`          // 当前的物流商简码
+	Candidates          []string `json:"candidates"`            // 还没有尝试的候选物流商
+	Attempt             int      `json:"attempt"`               // 当前尝试在 Attempts 中的位置
+	Done                bool     `json:"done"`                  // 是否已经结束（纠正成功或者已经恢复为原来的物流商）
+}
+
+// CourierCorrectionState 物流商纠正的状态
+type CourierCorrectionState struct {
+	Corrections []CourierCorrectionProgress `json:"corrections"` // 所有包裹的纠正进度
+	Attempts    []CourierCorrectionAttempt  `json:"attempts"`    // 所有尝试的记录
+}
+
+// CourierCorrectionStore 物流商纠正状态的存储
+type CourierCorrectionStore interface {
+	LoadCorrectionState() (CourierCorrectionState, error) // 读取状态，没有保存过时返回空的状态
+	SaveCorrectionState(state CourierCorrectionState) error
+}
+
+// FileCorrectionStore 使用 JSON 文件保存物流商纠正状态
+type FileCorrectionStore struct {
+	path string
+}
+
+func NewFileCorrectionStore(path string) *FileCorrectionStore {
+	return &FileCorrectionStore{path: path}
+}
+
+func (s *FileCorrectionStore) LoadCorrectionState() (state CourierCorrectionState, err error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	if len(b) != 0 {
+		err = json.Unmarshal(b, &state)
+	}
+	return
+}
+
+func (s *FileCorrectionStore) SaveCorrectionState(state CourierCorrectionState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b)
+}
+
+// CourierCorrector 纠正查询不到的包裹的物流商
+type CourierCorrector struct {
+	courier     CourierService
+	tracking    TrackingService
+	options     CourierCorrectionOptions
+	running     sync.Mutex // 保证同一时间只有一个 RunOnce 在执行，请求接口和读写 Store 时不持有 mu
+	mu          sync.Mutex // 保护 couriers 和 attempts
+	couriers    []Courier
+	corrections map[string]*courierCorrection // 只在 RunOnce 中访问
+	attempts    []CourierCorrectionAttempt
+	loaded      bool // 是否已经从 Store 中恢复状态
+	now         func() time.Time
+}
+
+func NewCourierCorrector(courier CourierService, tracking TrackingService, options CourierCorrectionOptions) *CourierCorrector {
+	if options.NotFoundDays <= 0 {
+		options.NotFoundDays = 7
+	}
+	if options.CheckDelay <= 0 {
+		options.CheckDelay = time.Hour
+	}
+	if options.PendingTimeout <= 0 {
+		options.PendingTimeout = 24 * time.Hour
+	}
+	if options.MaxCandidates <= 0 {
+		options.MaxCandidates = 3
+	}
+	if options.Interval <= 0 {
+		options.Interval = 10 * time.Minute
+	}
+	if options.Detector == nil {
+		options.Detector = DefaultCourierDetector
+	}
+	if options.Logger == nil {
+		options.Logger = defaultLogger
+	}
+	return &CourierCorrector{
+		courier:     courier,
+		tracking:    tracking,
+		options:     options,
+		corrections: make(map[string]*courierCorrection),
+		now:         time.Now,
+	}
+}
+
+// Candidates 返回包裹的候选物流商（不包括当前的物流商）
+//
+// 优先使用单号格式识别的物流商（只保留物流商列表中存在的物流商），S10 国际邮件单号再添加物流商列表中该国家的物流商。
+func (c *CourierCorrector) Candidates(track Track) ([]string, error) {
+	c.mu.Lock()
+	couriers := c.couriers
+	c.mu.Unlock()
+	if couriers == nil {
+		var err error
+		if couriers, err = c.courier.List(ChineseLanguage); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.couriers = couriers
+		c.mu.Unlock()
+	}
+
+	known := make(map[string]struct{}, len(couriers))
+	for _, courier := range couriers {
+		known[courier.Code] = struct{}{}
+	}
+
+	var candidates []string
+	seen := map[string]struct{}{track.CourierCode: {}}
+	add := func(code string) {
+		if _, ok := seen[code]; !ok && len(candidates) < c.options.MaxCandidates {
+			seen[code] = struct{}{}
+			candidates = append(candidates, code)
+		}
+	}
+	for _, code := range c.options.Detector.Detect(track.TrackingNumber) {
+		if _, ok := known[code]; ok {
+			add(code)
+		}
+	}
+	number := strings.ToUpper(strings.ReplaceAll(track.TrackingNumber, " ", ""))
+	if country, ok := s10Country(number); ok {
+		for _, courier := range couriers {
+			if strings.EqualFold(courier.CountryCode.String, country) {
+				add(courier.Code)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// Attempts 返回所有尝试的记录（设置了 Store 时，第一次执行 RunOnce 后才包括恢复的记录）
+func (c *CourierCorrector) Attempts() []CourierCorrectionAttempt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CourierCorrectionAttempt(nil), c.attempts...)
+}
+
+// 从 Store 中恢复状态（需要持有 running 锁）
+func (c *CourierCorrector) load() error {
+	if c.loaded || c.options.Store == nil {
+		return nil
+	}
+	state, err := c.options.Store.LoadCorrectionState()
+	if err != nil {
+		return err
+	}
+	for _, p := range state.Corrections {
+		if !p.Done && (p.Attempt < 0 || p.Attempt >= len(state.Attempts)) {
+			return languageErrorf(c.options.Language, "courier_correction_state_invalid", map[string]interface{}{"tracking_number": p.TrackingNumber})
+		}
+	}
+	for _, p := range state.Corrections {
+		c.corrections[p.TrackingNumber] = &courierCorrection{
+			trackingNumber:      p.TrackingNumber,
+			originalCourierCode: p.OriginalCourierCode,
+			courierCode:         p.CourierCode,
+			candidates:          p.Candidates,
+			attempt:             p.Attempt,
+			done:                p.Done,
+		}
+	}
+	c.mu.Lock()
+	c.attempts = state.Attempts
+	c.mu.Unlock()
+	c.loaded = true
+	return nil
+}
+
+// 保存状态到 Store（需要持有 running 锁）
+func (c *CourierCorrector) save() error {
+	if c.options.Store == nil {
+		return nil
+	}
+	state := CourierCorrectionState{Attempts: c.Attempts()}
+	for _, cc := range c.corrections {
+		state.Corrections = append(state.Corrections, CourierCorrectionProgress{
+			TrackingNumber:      cc.trackingNumber,
+			OriginalCourierCode: cc.originalCourierCode,
+			CourierCode:         cc.courierCode,
+			Candidates:          cc.candidates,
+			Attempt:             cc.attempt,
+			Done:                cc.done,
+		})
+	}
+	return c.options.Store.SaveCorrectionState(state)
+}
+
+// 添加尝试记录，返回记录的位置
+func (c *CourierCorrector) add(attempt CourierCorrectionAttempt, changed *[]CourierCorrectionAttempt) int {
+	c.mu.Lock()
+	c.attempts = append(c.attempts, attempt)
+	index := len(c.attempts) - 1
+	c.mu.Unlock()
+	*changed = append(*changed, attempt)
+	return index
+}
+
+// 更新尝试记录，changed 为 nil 时表示结果没有变化
+func (c *CourierCorrector) set(index int, attempt CourierCorrectionAttempt, changed *[]CourierCorrectionAttempt) {
+	c.mu.Lock()
+	c.attempts[index] = attempt
+	c.mu.Unlock()
+	if changed != nil {
+		*changed = append(*changed, attempt)
+	}
+}
+
+// 修改为下一个候选物流商，没有候选物流商时恢复为原来的物流商
+func (c *CourierCorrector) next(cc *courierCorrection, changed *[]CourierCorrectionAttempt) {
+	for len(cc.candidates) != 0 {
+		code := cc.candidates[0]
+		cc.candidates = cc.candidates[1:]
+		attempt := CourierCorrectionAttempt{
+			TrackingNumber:      cc.trackingNumber,
+			OriginalCourierCode: cc.originalCourierCode,
+			CourierCode:         code,
+			Result:              CourierCorrectionPending,
+			AttemptedAt:         c.now(),
+		}
+		if err := c.courier.Update(cc.trackingNumber, cc.courierCode, code); err != nil {
+			attempt.Result = CourierCorrectionUpdateFailed
+			attempt.Error = err.Error()
+			c.add(attempt, changed)
+			continue
+		}
+		cc.courierCode = code
+		cc.attempt = c.add(attempt, changed)
+		return
+	}
+
+	cc.done = true
+	if cc.courierCode == cc.originalCourierCode {
+		return
+	}
+	attempt := CourierCorrectionAttempt{
+		TrackingNumber:      cc.trackingNumber,
+		OriginalCourierCode: cc.originalCourierCode,
+		CourierCode:         cc.originalCourierCode,
+		Result:              CourierCorrectionRolledBack,
+		AttemptedAt:         c.now(),
+	}
+	if err := c.courier.Update(cc.trackingNumber, cc.courierCode, cc.originalCourierCode); err != nil {
+		attempt.Error = err.Error()
+	} else {
+		cc.courierCode = cc.originalCourierCode
+	}
+	c.add(attempt, changed)
+}
+
+// 检查等待中的尝试
+func (c *CourierCorrector) check(now time.Time, changed *[]CourierCorrectionAttempt) error {
+	c.mu.Lock()
+	var due []*courierCorrection
+	for _, cc := range c.corrections {
+		if !cc.done && now.Sub(c.attempts[cc.attempt].AttemptedAt) >= c.options.CheckDelay {
+			due = append(due, cc)
+		}
+	}
+	c.mu.Unlock()
+	for i := 0; i < len(due); i += 40 {
+		j := i + 40
+		if j > len(due) {
+			j = len(due)
+		}
+		numbers := make([]string, 0, j-i)
+		for _, cc := range due[i:j] {
+			numbers = append(numbers, cc.trackingNumber)
+		}
+		tracks, _, err := c.tracking.Query(TracksQueryParams{TrackingNumbers: strings.Join(numbers, ",")})
+		if err != nil {
+			return err
+		}
+		statuses := make(map[string]string, len(tracks))
+		courierCodes := make(map[string]string, len(tracks)) // 单号 => 物流商简码
+		for _, track := range tracks {
+			statuses[trackKey(track.TrackingNumber, track.CourierCode)] = track.DeliveryStatus
+			courierCodes[track.TrackingNumber] = track.CourierCode
+		}
+		for _, cc := range due[i:j] {
+			c.mu.Lock()
+			attempt := c.attempts[cc.attempt]
+			c.mu.Unlock()
+			status, ok := statuses[trackKey(cc.trackingNumber, cc.courierCode)]
+			if !ok {
+				if courierCode, exists := courierCodes[cc.trackingNumber]; exists {
+					// 物流商已被修改为其他物流商，不再修改物流商
+					attempt.CheckedAt = now
+					attempt.Result = CourierCorrectionAbandoned
+					attempt.Error = formatMessage(c.options.Language, "courier_correction_courier_changed", map[string]interface{}{"courier": courierCode})
+					cc.courierCode = courierCode
+					cc.done = true
+					c.set(cc.attempt, attempt, changed)
+				} else if now.Sub(attempt.AttemptedAt) >= c.options.PendingTimeout {
+					// 超过等待时间仍然没有返回该包裹，视为失败
+					attempt.CheckedAt = now
+					attempt.Result = CourierCorrectionFailed
+					attempt.Error = Message(c.options.Language, "courier_correction_track_missing")
+					c.set(cc.attempt, attempt, changed)
+					c.next(cc, changed)
+				}
+				continue
+			}
+			attempt.DeliveryStatus = status
+			attempt.CheckedAt = now
+			if status == StatusPending && now.Sub(attempt.AttemptedAt) < c.options.PendingTimeout {
+				// 51Tracking 仍在使用新的物流商查询，继续等待
+				c.set(cc.attempt, attempt, nil)
+				continue
+			}
+			if status != StatusNotFound && status != StatusPending {
+				attempt.Result = CourierCorrectionSucceeded
+				cc.done = true
+				c.set(cc.attempt, attempt, changed)
+				continue
+			}
+			attempt.Result = CourierCorrectionFailed
+			c.set(cc.attempt, attempt, changed)
+			c.next(cc, changed)
+		}
+	}
+	return nil
+}
+
+// RunOnce 检查等待中的尝试，并开始纠正新的查询不到的包裹，返回本次有变化的尝试记录
+//
+// 设置了 Store 时，第一次执行前会恢复之前保存的状态，每次执行后（包括出错时）都会保存状态。
+// OnAttempt 在执行结束后调用（不持有任何锁），可以在回调中调用 Attempts 等方法。
+func (c *CourierCorrector) RunOnce() (changed []CourierCorrectionAttempt, err error) {
+	defer func() {
+		if c.options.OnAttempt != nil {
+			for _, attempt := range changed {
+				c.options.OnAttempt(attempt)
+			}
+		}
+	}()
+	c.running.Lock()
+	defer c.running.Unlock()
+	if err = c.load(); err != nil {
+		return
+	}
+	defer func() {
+		if saveErr := c.save(); err == nil {
+			err = saveErr
+		}
+	}()
+	now := c.now()
+	if err = c.check(now, &changed); err != nil {
+		return
+	}
+
+	reader := c.tracking.Reader(TracksQueryParams{
+		DeliveryStatus: StatusNotFound,
+		CreatedDateMin: 1,
+		CreatedDateMax: now.AddDate(0, 0, -c.options.NotFoundDays).Unix(),
+	})
+	for {
+		track, e := reader.Read()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			return changed, e
+		}
+		if _, ok := c.corrections[track.TrackingNumber]; ok {
+			continue
+		}
+		candidates, e := c.Candidates(track)
+		if e != nil {
+			return changed, e
+		}
+		cc := &courierCorrection{
+			trackingNumber:      track.TrackingNumber,
+			originalCourierCode: track.CourierCode,
+			courierCode:         track.CourierCode,
+			candidates:          candidates,
+		}
+		c.corrections[track.TrackingNumber] = cc
+		c.next(cc, &changed)
+	}
+	return
+}
+
+// Run 定时纠正物流商，直到 ctx 被取消
+func (c *CourierCorrector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.options.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.RunOnce(); err != nil {
+			c.options.Logger.Printf("CourierCorrector run error: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package tracking51
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestCourierCorrector_RunOnce(t *testing.T) {
+	couriers := map[string]string{"RR123456785CN": "usps", "1Z999AA10123456784": "fedex"}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/courier":
+			writeTestResponse(w, []Courier{
+				{Code: "usps"}, {Code: "fedex"}, {Code: "ups"}, {Code: "china-post"}, {Code: "china-ems"},
+				{Code: "yanwen", CountryCode: null.StringFrom("CN")},
+			})
+		case "/modifycourier":
+			var req map[string]string
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &req)
+			if couriers[req["tracking_number"]] != req["courier_code"] {
+				t.Errorf("unexpected old courier code: %#v", req)
+			}
+			couriers[req["tracking_number"]] = req["new_courier_code"]
+			writeTestResponse(w, nil)
+		case "/get":
+			var tracks []Track
+			numbers := r.URL.Query().Get("tracking_numbers")
+			for number, courierCode := range couriers {
+				if numbers != "" && !strings.Contains(numbers, number) {
+					continue
+				}
+				status := StatusNotFound
+				if courierCode == "china-ems" {
+					status = StatusTransit
+				}
+				tracks = append(tracks, Track{TrackingNumber: number, CourierCode: courierCode, DeliveryStatus: status})
+			}
+			writeTestResponse(w, tracks)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	var results []string
+	var corrector *CourierCorrector
+	corrector = NewCourierCorrector(c.Services.Courier, c.Services.Tracking, CourierCorrectionOptions{
+		OnAttempt: func(attempt CourierCorrectionAttempt) {
+			// 回调在 RunOnce 释放锁之后执行
+			if len(corrector.Attempts()) == 0 {
+				t.Error("expected attempts")
+			}
+			results = append(results, attempt.TrackingNumber+":"+attempt.CourierCode+":"+string(attempt.Result))
+		},
+	})
+	now := time.Now()
+	corrector.now = func() time.Time { return now }
+
+	candidates, err := corrector.Candidates(Track{TrackingNumber: "RR123456785CN", CourierCode: "usps"})
+	if err != nil || strings.Join(candidates, ",") != "china-post,china-ems,yanwen" {
+		t.Fatalf("unexpected candidates: %v, %v", candidates, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err = corrector.RunOnce(); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Hour)
+	}
+	if couriers["RR123456785CN"] != "china-ems" || couriers["1Z999AA10123456784"] != "fedex" {
+		t.Errorf("unexpected couriers: %v", couriers)
+	}
+
+	attempts := corrector.Attempts()
+	var got []string
+	for _, attempt := range attempts {
+		got = append(got, attempt.TrackingNumber+":"+attempt.CourierCode+":"+string(attempt.Result))
+	}
+	for _, expected := range []string{
+		"RR123456785CN:china-post:failed",
+		"RR123456785CN:china-ems:succeeded",
+		"1Z999AA10123456784:ups:failed",
+		"1Z999AA10123456784:fedex:rolled_back",
+	} {
+		found := false
+		for _, s := range got {
+			found = found || s == expected
+		}
+		if !found {
+			t.Errorf("expected attempt %s, got %v", expected, got)
+		}
+	}
+	if len(attempts) != 4 || len(results) != 7 {
+		t.Errorf("expected 4 attempts and 7 callbacks, got %d and %d", len(attempts), len(results))
+	}
+}
+
+func TestCourierCorrector_Restore(t *testing.T) {
+	couriers := map[string]string{"1Z999AA10123456784": "fedex"}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/courier":
+			writeTestResponse(w, []Courier{{Code: "fedex"}, {Code: "ups"}})
+		case "/modifycourier":
+			var req map[string]string
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &req)
+			couriers[req["tracking_number"]] = req["new_courier_code"]
+			writeTestResponse(w, nil)
+		case "/get":
+			var tracks []Track
+			for number, courierCode := range couriers {
+				status := StatusNotFound
+				if courierCode == "ups" {
+					// 修改物流商后 51Tracking 一直在查询
+					status = StatusPending
+				}
+				tracks = append(tracks, Track{TrackingNumber: number, CourierCode: courierCode, DeliveryStatus: status})
+			}
+			writeTestResponse(w, tracks)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	store := NewFileCorrectionStore(t.TempDir() + "/corrections.json")
+	now := time.Now()
+	newCorrector := func() *CourierCorrector {
+		corrector := NewCourierCorrector(c.Services.Courier, c.Services.Tracking, CourierCorrectionOptions{Store: store})
+		corrector.now = func() time.Time { return now }
+		return corrector
+	}
+
+	if _, err := newCorrector().RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if couriers["1Z999AA10123456784"] != "ups" {
+		t.Fatalf("expected courier to be switched to ups, got %s", couriers["1Z999AA10123456784"])
+	}
+
+	// 重启后继续等待“查询中”的包裹
+	now = now.Add(2 * time.Hour)
+	corrector := newCorrector()
+	changed, err := corrector.RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 || couriers["1Z999AA10123456784"] != "ups" {
+		t.Errorf("pending parcel should keep waiting, changed: %v, courier: %s", changed, couriers["1Z999AA10123456784"])
+	}
+	if attempts := corrector.Attempts(); len(attempts) != 1 || attempts[0].DeliveryStatus != StatusPending {
+		t.Errorf("expected restored attempt, got %v", attempts)
+	}
+
+	// 超过 PendingTimeout 后视为失败，重启后仍然可以恢复为原来的物流商
+	now = now.Add(24 * time.Hour)
+	if changed, err = newCorrector().RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || changed[0].Result != CourierCorrectionFailed || changed[1].Result != CourierCorrectionRolledBack {
+		t.Errorf("unexpected changed attempts: %v", changed)
+	}
+	if couriers["1Z999AA10123456784"] != "fedex" {
+		t.Errorf("expected courier to be rolled back to fedex, got %s", couriers["1Z999AA10123456784"])
+	}
+
+	state, err := store.LoadCorrectionState()
+	if err != nil || len(state.Corrections) != 1 || !state.Corrections[0].Done || len(state.Attempts) != 2 {
+		t.Errorf("unexpected saved state: %#v, %v", state, err)
+	}
+}
+
+func TestCourierCorrector_CourierChanged(t *testing.T) {
+	couriers := map[string]string{"1Z999AA10123456784": "fedex"}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/courier":
+			writeTestResponse(w, []Courier{{Code: "fedex"}, {Code: "ups"}})
+		case "/modifycourier":
+			var req map[string]string
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &req)
+			couriers[req["tracking_number"]] = req["new_courier_code"]
+			writeTestResponse(w, nil)
+		case "/get":
+			var tracks []Track
+			for number, courierCode := range couriers {
+				tracks = append(tracks, Track{TrackingNumber: number, CourierCode: courierCode, DeliveryStatus: StatusNotFound})
+			}
+			writeTestResponse(w, tracks)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	corrector := NewCourierCorrector(c.Services.Courier, c.Services.Tracking, CourierCorrectionOptions{Language: EnglishLanguage})
+	now := time.Now()
+	corrector.now = func() time.Time { return now }
+	if _, err := corrector.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if couriers["1Z999AA10123456784"] != "ups" {
+		t.Fatalf("expected courier to be switched to ups, got %s", couriers["1Z999AA10123456784"])
+	}
+
+	// 物流商被手动修改
+	couriers["1Z999AA10123456784"] = "dhl"
+	now = now.Add(2 * time.Hour)
+	changed, err := corrector.RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0].Result != CourierCorrectionAbandoned || changed[0].Error != "The courier has been changed to dhl, correction abandoned" {
+		t.Errorf("unexpected changed attempts: %v", changed)
+	}
+	if couriers["1Z999AA10123456784"] != "dhl" {
+		t.Errorf("courier should not be changed, got %s", couriers["1Z999AA10123456784"])
+	}
+
+	now = now.Add(2 * time.Hour)
+	if changed, err = corrector.RunOnce(); err != nil || len(changed) != 0 {
+		t.Errorf("abandoned correction should not be checked again, changed: %v, error: %v", changed, err)
+	}
+}
@@ -0,0 +1,88 @@
+package tracking51
+
+import "testing"
+
+func TestLookupCountry(t *testing.T) {
+	if len(Countries()) != 249 {
+		t.Errorf("expected 249 countries, got %d", len(Countries()))
+	}
+	c, ok := LookupCountry("cn")
+	if !ok || c.Code != "CN" || c.CallingCode != "86" || c.EnglishName != "China" {
+		t.Errorf("unexpected country: %#v", c)
+	}
+	if IsCountryCode("XX") || IsCountryCode("UK") {
+		t.Error("expected XX and UK to be invalid")
+	}
+	if name := CountryName("BR", EnglishLanguage); name != "Brazil" {
+		t.Errorf("unexpected name: %s", name)
+	}
+	for _, s := range []string{"Brazil", "巴西"} {
+		if code, ok := lookupLocationCountry(s); !ok || code != "BR" {
+			t.Errorf("%s: expected BR, got %s", s, code)
+		}
+	}
+}
+
+func TestValidatePhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		valid bool
+	}{
+		{"+8613800138000", true},
+		{"+12025550123", true},
+		{"+442071234567", true},
+		{"+85291234567", true},
+		{"+2348031234567", true},
+		{"+86138", false},
+		{"+1202555012", false},
+		{"8613800138000", false},
+		{"+86 13800138000", false},
+		{"+0123456789", false},
+	}
+	for _, test := range tests {
+		if err := ValidatePhone(test.phone); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.phone, test.valid, err)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone    string
+		country  string
+		expected string
+	}{
+		{"138 0013 8000", "CN", "+8613800138000"},
+		{"020 7123 4567", "gb", "+442071234567"},
+		{"(202) 555-0123", "US", "+12025550123"},
+		{"1-202-555-0123", "US", "+12025550123"},
+		{"8 912 345 67 89", "RU", "+79123456789"},
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"0039 06 1234 5678", "", "+390612345678"},
+		{"+86 138-0013-8000", "", "+8613800138000"},
+	}
+	for _, test := range tests {
+		phone, err := NormalizePhone(test.phone, test.country)
+		if err != nil || phone != test.expected {
+			t.Errorf("%s, %s: expected %s, got %s, %v", test.phone, test.country, test.expected, phone, err)
+		}
+	}
+	if _, err := NormalizePhone("12345", "XX"); err == nil {
+		t.Error("expected error for invalid country")
+	}
+}
+
+func TestCreateTrackRequest_Validate(t *testing.T) {
+	req := CreateTrackRequest{TrackingNumber: "A", CourierCode: "usps", DestinationCode: "US", CustomerPhone: "+12025550123"}
+	if err := req.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	req.DestinationCode = "XX"
+	if err := req.Validate(); err == nil {
+		t.Error("expected error for invalid destination code")
+	}
+	req.DestinationCode, req.CustomerPhone = "US", "+1202555"
+	if err := req.Validate(); err == nil {
+		t.Error("expected error for invalid phone")
+	}
+}
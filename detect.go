@@ -0,0 +1,129 @@
+package tracking51
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 根据单号格式识别物流商
+
+// UPU S10 国际邮件单号：两位业务代码 + 8 位序号 + 1 位校验位 + 两位国家简码
+var s10Pattern = regexp.MustCompile(`^[A-Z]{2}(\d{8})(\d)([A-Z]{2})$`)
+
+// S10 单号国家简码对应的邮政物流商
+var postalCouriers = map[string][]string{
+	"AU": {"australia-post"},
+	"CA": {"canada-post"},
+	"CN": {"china-post", "china-ems"},
+	"DE": {"deutsch-post"},
+	"FR": {"laposte"},
+	"GB": {"royal-mail"},
+	"HK": {"hong-kong-post"},
+	"JP": {"japan-post"},
+	"NL": {"postnl-parcels"},
+	"SG": {"singapore-post"},
+	"US": {"usps"},
+}
+
+// 内置的单号格式
+var builtinCourierPatterns = []struct {
+	courierCode string
+	pattern     string
+}{
+	{"ups", `^1Z[0-9A-Z]{16}$`},
+	{"usps", `^(92|93|94|95)\d{20}$`},
+	{"fedex", `^(\d{12}|\d{15}|\d{20})$`},
+	{"dhl", `^\d{10}$`},
+	{"yunexpress", `^YT\d{16}$`},
+	{"4px", `^4PX\d{10,}$`},
+	{"cainiao", `^LP\d{14}$`},
+	{"sf-express", `^SF\d{12,13}$`},
+}
+
+// 检查 S10 单号的校验位
+func validS10(serial, checkDigit string) bool {
+	weights := [8]int{8, 6, 4, 2, 3, 5, 9, 7}
+	sum := 0
+	for i, w := range weights {
+		sum += int(serial[i]-'0') * w
+	}
+	d := 11 - sum%11
+	if d == 10 {
+		d = 0
+	} else if d == 11 {
+		d = 5
+	}
+	return int(checkDigit[0]-'0') == d
+}
+
+// 返回 S10 单号的国家简码，不是有效的 S10 单号时 ok 为 false
+func s10Country(trackingNumber string) (code string, ok bool) {
+	m := s10Pattern.FindStringSubmatch(trackingNumber)
+	if m == nil || !validS10(m[1], m[2]) {
+		return "", false
+	}
+	return m[3], true
+}
+
+type courierPattern struct {
+	courierCode string
+	pattern     *regexp.Regexp
+}
+
+// CourierDetector 根据单号格式返回可能的物流商
+type CourierDetector struct {
+	mu       sync.RWMutex
+	patterns []courierPattern
+}
+
+// NewCourierDetector 创建包含内置单号格式的识别器
+func NewCourierDetector() *CourierDetector {
+	d := &CourierDetector{}
+	for _, p := range builtinCourierPatterns {
+		d.patterns = append(d.patterns, courierPattern{courierCode: p.courierCode, pattern: regexp.MustCompile(p.pattern)})
+	}
+	return d
+}
+
+// DefaultCourierDetector 默认的识别器
+var DefaultCourierDetector = NewCourierDetector()
+
+// Add 添加物流商的单号格式（正则表达式），添加的格式优先于内置的格式
+func (d *CourierDetector) Add(courierCode, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.patterns = append([]courierPattern{{courierCode: courierCode, pattern: re}}, d.patterns...)
+	return nil
+}
+
+// Detect 返回单号可能对应的物流商简码（按可能性排序）
+func (d *CourierDetector) Detect(trackingNumber string) []string {
+	trackingNumber = strings.ToUpper(strings.ReplaceAll(trackingNumber, " ", ""))
+	var codes []string
+	seen := make(map[string]struct{})
+	add := func(code string) {
+		if _, ok := seen[code]; !ok {
+			seen[code] = struct{}{}
+			codes = append(codes, code)
+		}
+	}
+
+	d.mu.RLock()
+	for _, p := range d.patterns {
+		if p.pattern.MatchString(trackingNumber) {
+			add(p.courierCode)
+		}
+	}
+	d.mu.RUnlock()
+	if country, ok := s10Country(trackingNumber); ok {
+		for _, code := range postalCouriers[country] {
+			add(code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,32 @@
+package tracking51
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCourierDetector_Detect(t *testing.T) {
+	d := NewCourierDetector()
+	for number, expected := range map[string][]string{
+		"1Z999AA10123456784":          {"ups"},
+		"9400 1000 0000 0000 0000 00": {"usps"},
+		"RR123456785CN":               {"china-post", "china-ems"},
+		"RR123456784CN":               nil, // 校验位错误
+		"YT1234567890123456":          {"yunexpress"},
+		"ABC":                         nil,
+	} {
+		if codes := d.Detect(number); !reflect.DeepEqual(codes, expected) {
+			t.Errorf("%s: expected %v, got %v", number, expected, codes)
+		}
+	}
+
+	if err := d.Add("my-express", `^ME\d{8}$`); err != nil {
+		t.Fatal(err)
+	}
+	if codes := d.Detect("me12345678"); !reflect.DeepEqual(codes, []string{"my-express"}) {
+		t.Errorf("unexpected codes: %v", codes)
+	}
+	if err := d.Add("bad", `(`); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,158 @@
+package tracking51
+
+import (
+	"time"
+)
+
+// 包裹变化检测
+
+// ChangeType 变化类型
+type ChangeType string
+
+const (
+	CheckpointAddedChange  ChangeType = "checkpoint_added"  // 新增物流节点
+	StatusTransitionChange ChangeType = "status_transition" // 包裹状态变化
+	FieldChange            ChangeType = "field"             // 字段值变化
+)
+
+// 事件来源
+const (
+	WebhookEventSource = "webhook" // Webhook 推送
+	PollingEventSource = "polling" // 轮询查询
+)
+
+// Change 包裹变化
+type Change struct {
+	Type       ChangeType        `json:"type"`                 // 变化类型
+	Field      string            `json:"field,omitempty"`      // 变化的字段（JSON 字段名）
+	From       string            `json:"from,omitempty"`       // 变化前的值
+	To         string            `json:"to,omitempty"`         // 变化后的值
+	Source     string            `json:"source,omitempty"`     // 新增物流节点的来源（origin, destination）
+	Checkpoint *TrackInformation `json:"checkpoint,omitempty"` // 新增的物流节点
+}
+
+// 需要比较的字段
+var diffFields = []struct {
+	name  string
+	value func(t Track) string
+}{
+	{"substatus", func(t Track) string { return t.SubStatus }},
+	{"consignee", func(t Track) string { return t.Consignee }},
+	{"destination_track_number", func(t Track) string { return t.DestinationTrackNumber }},
+	{"exchangeNumber", func(t Track) string { return t.ExchangeNumber }},
+	{"service_code", func(t Track) string { return t.ServiceCode }},
+	{"weight", func(t Track) string { return t.Weight }},
+	{"status_info", func(t Track) string { return t.StatusInfo }},
+	{"latest_event", func(t Track) string { return t.LatestEvent }},
+	{"lastest_checkpoint_time", func(t Track) string { return t.LatestCheckpointTime }},
+	{"destination", func(t Track) string { return t.Destination }},
+	{"destination_country", func(t Track) string { return t.DestinationCountry }},
+	{"original", func(t Track) string { return t.Original }},
+	{"origin_info.courier_code", func(t Track) string { return t.OriginInfo.CourierCode }},
+	{"origin_info.received_date", func(t Track) string { return t.OriginInfo.ReceivedDate }},
+	{"origin_info.dispatched_date", func(t Track) string { return t.OriginInfo.DispatchedDate }},
+	{"origin_info.departed_airport_date", func(t Track) string { return t.OriginInfo.DepartedAirportDate }},
+	{"origin_info.arrived_abroad_date", func(t Track) string { return t.OriginInfo.ArrivedAbroadDate }},
+	{"origin_info.customs_received_date", func(t Track) string { return t.OriginInfo.CustomsReceivedDate }},
+	{"origin_info.arrived_destination_date", func(t Track) string { return t.OriginInfo.ArrivedDestinationDate }},
+	{"destination_info.courier_code", func(t Track) string { return t.DestinationInfo.CourierCode }},
+	{"destination_info.received_date", func(t Track) string { return t.DestinationInfo.ReceivedDate }},
+	{"destination_info.dispatched_date", func(t Track) string { return t.DestinationInfo.DispatchedDate }},
+	{"destination_info.departed_airport_date", func(t Track) string { return t.DestinationInfo.DepartedAirportDate }},
+	{"destination_info.arrived_abroad_date", func(t Track) string { return t.DestinationInfo.ArrivedAbroadDate }},
+	{"destination_info.customs_received_date", func(t Track) string { return t.DestinationInfo.CustomsReceivedDate }},
+	{"destination_info.arrived_destination_date", func(t Track) string { return t.DestinationInfo.ArrivedDestinationDate }},
+	{"archived", func(t Track) string {
+		if t.Archived {
+			return "true"
+		}
+		return "false"
+	}},
+	{"updating", func(t Track) string {
+		if t.Updating {
+			return "true"
+		}
+		return "false"
+	}},
+}
+
+func addedCheckpoints(source string, old, new []TrackInformation) []Change {
+	keys := make(map[string]struct{}, len(old))
+	for _, c := range old {
+		keys[checkpointKey(c)] = struct{}{}
+	}
+	var changes []Change
+	for i := range new {
+		if _, ok := keys[checkpointKey(new[i])]; !ok {
+			c := new[i]
+			changes = append(changes, Change{Type: CheckpointAddedChange, Source: source, Checkpoint: &c})
+		}
+	}
+	return changes
+}
+
+// DiffTrack 比较同一包裹的两次查询结果，返回 old 到 new 之间的变化
+//
+// 返回的变化依次为：包裹状态变化、新增的物流节点（先发件国后目的国）、其他字段的变化
+func DiffTrack(old, new Track) []Change {
+	var changes []Change
+	if old.DeliveryStatus != new.DeliveryStatus {
+		changes = append(changes, Change{Type: StatusTransitionChange, Field: "delivery_status", From: old.DeliveryStatus, To: new.DeliveryStatus})
+	}
+	changes = append(changes, addedCheckpoints("origin", old.OriginInfo.TrackInfo, new.OriginInfo.TrackInfo)...)
+	changes = append(changes, addedCheckpoints("destination", old.DestinationInfo.TrackInfo, new.DestinationInfo.TrackInfo)...)
+	for _, field := range diffFields {
+		if from, to := field.value(old), field.value(new); from != to {
+			changes = append(changes, Change{Type: FieldChange, Field: field.name, From: from, To: to})
+		}
+	}
+	return changes
+}
+
+// TrackEvent 包裹变化事件
+type TrackEvent struct {
+	TrackingNumber string    `json:"tracking_number"` // 包裹物流单号
+	CourierCode    string    `json:"courier_code"`    // 物流商对应的唯一简码
+	Source         string    `json:"source"`          // 事件来源（webhook, polling）
+	Time           time.Time `json:"time"`            // 事件发生时间
+	Track          Track     `json:"track"`           // 最新的包裹数据
+	Changes        []Change  `json:"changes"`         // 变化列表
+}
+
+// NewTrackEvent 根据包裹的前后两次数据生成变化事件
+func NewTrackEvent(source string, old, new Track) TrackEvent {
+	return TrackEvent{
+		TrackingNumber: new.TrackingNumber,
+		CourierCode:    new.CourierCode,
+		Source:         source,
+		Time:           time.Now(),
+		Track:          new,
+		Changes:        DiffTrack(old, new),
+	}
+}
+
+// HasChanges 是否有变化
+func (e TrackEvent) HasChanges() bool {
+	return len(e.Changes) != 0
+}
+
+// StatusTransition 返回包裹状态变化，没有变化时 ok 为 false
+func (e TrackEvent) StatusTransition() (from, to string, ok bool) {
+	for _, c := range e.Changes {
+		if c.Type == StatusTransitionChange {
+			return c.From, c.To, true
+		}
+	}
+	return
+}
+
+// AddedCheckpoints 返回新增的物流节点
+func (e TrackEvent) AddedCheckpoints() []TrackInformation {
+	var items []TrackInformation
+	for _, c := range e.Changes {
+		if c.Type == CheckpointAddedChange && c.Checkpoint != nil {
+			items = append(items, *c.Checkpoint)
+		}
+	}
+	return items
+}
@@ -0,0 +1,34 @@
+package tracking51
+
+import "testing"
+
+func TestDiffTrack(t *testing.T) {
+	old := Track{
+		TrackingNumber: "A",
+		DeliveryStatus: StatusTransit,
+		OriginInfo: Information{TrackInfo: []TrackInformation{
+			{CheckpointDate: "2022-07-01 10:00:00", TrackingDetail: "Accepted"},
+		}},
+	}
+	updated := old
+	updated.DeliveryStatus = StatusPickup
+	updated.Consignee = "Tom"
+	updated.OriginInfo.TrackInfo = append([]TrackInformation{{CheckpointDate: "2022-07-05 10:00:00", TrackingDetail: "Available for pickup"}}, old.OriginInfo.TrackInfo...)
+
+	event := NewTrackEvent(PollingEventSource, old, updated)
+	if len(event.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %#v", event.Changes)
+	}
+	if from, to, ok := event.StatusTransition(); !ok || from != StatusTransit || to != StatusPickup {
+		t.Errorf("unexpected status transition: %s -> %s", from, to)
+	}
+	if checkpoints := event.AddedCheckpoints(); len(checkpoints) != 1 || checkpoints[0].TrackingDetail != "Available for pickup" {
+		t.Errorf("unexpected added checkpoints: %#v", checkpoints)
+	}
+	if c := event.Changes[2]; c.Type != FieldChange || c.Field != "consignee" || c.To != "Tom" {
+		t.Errorf("unexpected field change: %#v", c)
+	}
+	if DiffTrack(updated, updated) != nil {
+		t.Error("same track should have no changes")
+	}
+}
@@ -0,0 +1,342 @@
+package tracking51
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 预计送达时间
+
+// ETA 预计送达时间
+type ETA struct {
+	Earliest   time.Time `json:"earliest"`   // 最早送达时间
+	Expected   time.Time `json:"expected"`   // 预计送达时间
+	Latest     time.Time `json:"latest"`     // 最晚送达时间
+	Confidence float64   `json:"confidence"` // 置信度（0~1）
+	Source     string    `json:"source"`     // 数据来源（delivered: 已签收, history: 历史数据, transit_time: 51Tracking 时效数据）
+}
+
+// ETAOptions 预计送达时间选项
+type ETAOptions struct {
+	CacheTTL   time.Duration // 时效数据和历史数据索引的缓存时间，默认为 24 小时
+	History    Store         // 历史包裹数据（可选），同一线路的已签收包裹数量达到 MinHistory 时优先使用历史数据（按线路建立索引后缓存，不会每次预计都读取所有包裹）
+	MinHistory int           // 使用历史数据的最少包裹数量，默认为 20
+	Language   string        // 错误信息的语言（可以使用 Tracking51.Language()），默认为中文
+}
+
+type cachedTransitTime struct {
+	transitTime TransitTime
+	found       bool
+	fetchedAt   time.Time
+}
+
+// Estimator 根据线路（物流商、发件国、目的国）的时效数据和包裹当前的进度预计送达时间
+type Estimator struct {
+	tracking TrackingService
+	options  ETAOptions
+	mu       sync.Mutex // 保护缓存，读取历史数据和请求接口时不持有
+	loading  sync.Mutex // 保证同一时间只有一个 goroutine 建立历史数据索引
+	cache    map[string]cachedTransitTime
+	history  map[string][]float64 // 线路 => 历史包裹的送达天数（已排序）
+	loadedAt time.Time            // 历史数据索引的建立时间
+	now      func() time.Time
+}
+
+func NewEstimator(tracking TrackingService, options ETAOptions) *Estimator {
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = 24 * time.Hour
+	}
+	if options.MinHistory <= 0 {
+		options.MinHistory = 20
+	}
+	return &Estimator{
+		tracking: tracking,
+		options:  options,
+		cache:    make(map[string]cachedTransitTime),
+		now:      time.Now,
+	}
+}
+
+// 转换为国家二字简码
+func countryCode(s string) string {
+	if code, ok := lookupLocationCountry(s); ok {
+		return strings.ToUpper(code)
+	}
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+func laneKey(courierCode, originalCode, destinationCode string) string {
+	return courierCode + "|" + originalCode + "|" + destinationCode
+}
+
+// 包裹的起始时间：上网时间，其次是发货时间、创建时间
+func trackStartTime(track Track) time.Time {
+	if t := TrackMilestones(track).Received; !t.IsZero() {
+		return t
+	}
+	return firstTime(track.OrderCreateTime, track.CreatedAt)
+}
+
+// TransitTime 获取线路的时效数据（有缓存）
+func (e *Estimator) TransitTime(courierCode, originalCode, destinationCode string) (tt TransitTime, found bool, err error) {
+	key := laneKey(courierCode, originalCode, destinationCode)
+	e.mu.Lock()
+	cached, ok := e.cache[key]
+	e.mu.Unlock()
+	if ok && e.now().Sub(cached.fetchedAt) < e.options.CacheTTL {
+		return cached.transitTime, cached.found, nil
+	}
+
+	success, _, err := e.tracking.TransitTime(TransitTimeRequests{{
+		CourierCode:     courierCode,
+		OriginalCode:    originalCode,
+		DestinationCode: destinationCode,
+	}})
+	if err != nil {
+		return
+	}
+	for _, item := range success {
+		if item.CourierCode == courierCode && strings.EqualFold(item.OriginalCode, originalCode) && strings.EqualFold(item.DestinationCode, destinationCode) {
+			tt, found = item, true
+			break
+		}
+	}
+	e.mu.Lock()
+	e.cache[key] = cachedTransitTime{transitTime: tt, found: found, fetchedAt: e.now()}
+	e.mu.Unlock()
+	return
+}
+
+// 时效数据的送达天数区间
+var transitTimeRanges = [][2]float64{{0, 7}, {7, 15}, {15, 30}, {30, 60}, {60, 90}}
+
+// 送达天数分布
+type transitDistribution struct {
+	weights []float64 // 各区间的占比
+}
+
+func newTransitDistribution(tt TransitTime) (d transitDistribution, ok bool) {
+	d.weights = []float64{tt.Range1To7, tt.Range8To15, tt.Range16To30, tt.Range31To60, tt.Range60Up}
+	sum := 0.0
+	for _, w := range d.weights {
+		sum += w
+	}
+	if sum <= 0 {
+		return d, false
+	}
+	for i := range d.weights {
+		d.weights[i] /= sum
+	}
+	return d, true
+}
+
+// quantile 返回已经运输 elapsed 天的条件下，送达天数的 q 分位数（区间内按均匀分布插值）
+func (d transitDistribution) quantile(q, elapsed float64) float64 {
+	// 去掉 elapsed 之前的占比
+	weights := make([]float64, len(d.weights))
+	sum := 0.0
+	for i, r := range transitTimeRanges {
+		w := d.weights[i]
+		if elapsed >= r[1] {
+			w = 0
+		} else if elapsed > r[0] {
+			w *= (r[1] - elapsed) / (r[1] - r[0])
+		}
+		weights[i] = w
+		sum += w
+	}
+	if sum <= 0 {
+		return elapsed
+	}
+
+	target := q * sum
+	for i, r := range transitTimeRanges {
+		lower := math.Max(r[0], elapsed)
+		if weights[i] == 0 {
+			continue
+		}
+		if target <= weights[i] {
+			return lower + (r[1]-lower)*target/weights[i]
+		}
+		target -= weights[i]
+	}
+	return transitTimeRanges[len(transitTimeRanges)-1][1]
+}
+
+// 历史包裹的送达天数（已排序），历史数据索引超过缓存时间时重新建立
+func (e *Estimator) historyDays(courierCode, originalCode, destinationCode string) ([]float64, error) {
+	if e.options.History == nil {
+		return nil, nil
+	}
+	key := laneKey(courierCode, originalCode, destinationCode)
+	if history, ok := e.freshHistory(); ok {
+		return history[key], nil
+	}
+
+	e.loading.Lock()
+	defer e.loading.Unlock()
+	// 等待期间其他 goroutine 可能已经重新建立了索引
+	if history, ok := e.freshHistory(); ok {
+		return history[key], nil
+	}
+	history, err := e.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	return history[key], nil
+}
+
+// 返回未超过缓存时间的历史数据索引
+func (e *Estimator) freshHistory() (map[string][]float64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.history == nil || e.now().Sub(e.loadedAt) >= e.options.CacheTTL {
+		return nil, false
+	}
+	return e.history, true
+}
+
+// 按线路建立历史数据索引（读取历史数据时不持有 mu）
+func (e *Estimator) loadHistory() (map[string][]float64, error) {
+	tracks, err := e.options.History.Tracks()
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[string][]float64)
+	for _, t := range tracks {
+		if t.DeliveryStatus != StatusDelivered {
+			continue
+		}
+		key := laneKey(t.CourierCode, countryCode(t.Original), countryCode(t.Destination))
+		if t.TransitTime > 0 {
+			history[key] = append(history[key], float64(t.TransitTime))
+			continue
+		}
+		start, end := trackStartTime(t), firstTime(t.LatestCheckpointTime)
+		if !start.IsZero() && end.After(start) {
+			history[key] = append(history[key], end.Sub(start).Hours()/24)
+		}
+	}
+	for _, days := range history {
+		sort.Float64s(days)
+	}
+	e.mu.Lock()
+	e.history, e.loadedAt = history, e.now()
+	e.mu.Unlock()
+	return history, nil
+}
+
+// ReloadHistory 立即重新建立历史数据索引（历史包裹数据有大量变化时使用）
+func (e *Estimator) ReloadHistory() error {
+	if e.options.History == nil {
+		return nil
+	}
+	e.loading.Lock()
+	defer e.loading.Unlock()
+	_, err := e.loadHistory()
+	return err
+}
+
+// 已经运输 elapsed 天的条件下，历史送达天数的 q 分位数
+func historyQuantile(days []float64, q, elapsed float64) float64 {
+	i := sort.SearchFloat64s(days, elapsed)
+	rest := days[i:]
+	if len(rest) == 0 {
+		return elapsed
+	}
+	return rest[int(math.Round(q*float64(len(rest)-1)))]
+}
+
+func addDays(t time.Time, days float64) time.Time {
+	return t.Add(time.Duration(days * 24 * float64(time.Hour)))
+}
+
+// Estimate 预计包裹的送达时间
+//
+// 已经到达目的城市或者到达待取的包裹，预计在到达后 3 天内送达。
+func (e *Estimator) Estimate(track Track) (eta ETA, err error) {
+	if track.DeliveryStatus == StatusDelivered {
+		if t := firstTime(track.LatestCheckpointTime, track.UpdateDate); !t.IsZero() {
+			return ETA{Earliest: t, Expected: t, Latest: t, Confidence: 1, Source: "delivered"}, nil
+		}
+	}
+
+	start := trackStartTime(track)
+	if start.IsZero() {
+		return eta, languageErrorf(e.options.Language, "eta_start_time_missing", nil)
+	}
+	now := e.now()
+	elapsed := math.Max(now.Sub(start).Hours()/24, 0)
+	courierCode := track.CourierCode
+	originalCode, destinationCode := countryCode(track.Original), countryCode(track.Destination)
+
+	days, err := e.historyDays(courierCode, originalCode, destinationCode)
+	if err != nil {
+		return
+	}
+	var earliest, expected, latest float64
+	if len(days) >= e.options.MinHistory {
+		eta.Source = "history"
+		earliest = historyQuantile(days, 0.1, elapsed)
+		expected = historyQuantile(days, 0.5, elapsed)
+		latest = historyQuantile(days, 0.9, elapsed)
+		eta.Confidence = float64(len(days)) / float64(len(days)+20)
+	} else {
+		tt, found, ttErr := e.TransitTime(courierCode, originalCode, destinationCode)
+		if ttErr != nil {
+			return eta, ttErr
+		}
+		d, ok := newTransitDistribution(tt)
+		if !found || !ok {
+			return eta, languageErrorf(e.options.Language, "eta_transit_time_missing", nil)
+		}
+		eta.Source = "transit_time"
+		earliest = d.quantile(0.1, elapsed)
+		expected = d.quantile(0.5, elapsed)
+		latest = d.quantile(0.9, elapsed)
+		if tt.AverageDeliveryTime > elapsed && tt.AverageDeliveryTime >= earliest && tt.AverageDeliveryTime <= latest {
+			expected = tt.AverageDeliveryTime
+		}
+		n := float64(tt.Delivered)
+		eta.Confidence = n / (n + 50)
+	}
+	// 已经超过大部分包裹的送达时间，降低置信度
+	if elapsed > latest {
+		eta.Confidence /= 2
+	}
+	eta.Earliest, eta.Expected, eta.Latest = addDays(start, earliest), addDays(start, expected), addDays(start, latest)
+
+	arrivedAt := TrackMilestones(track).ArrivedDestination
+	if arrivedAt.IsZero() && track.DeliveryStatus == StatusPickup {
+		arrivedAt = firstTime(track.LatestCheckpointTime)
+	}
+	if !arrivedAt.IsZero() {
+		eta.Earliest = arrivedAt
+		if now.After(arrivedAt) {
+			eta.Earliest = now
+		}
+		eta.Expected = addDays(arrivedAt, 1)
+		eta.Latest = addDays(arrivedAt, 3)
+		if eta.Expected.Before(eta.Earliest) {
+			eta.Expected = eta.Earliest
+		}
+		if eta.Latest.Before(eta.Expected) {
+			eta.Latest = eta.Expected
+		}
+		eta.Confidence = math.Min(eta.Confidence+0.2, 0.95)
+	}
+	if eta.Earliest.Before(now) {
+		eta.Earliest = now
+	}
+	if eta.Expected.Before(eta.Earliest) {
+		eta.Expected = eta.Earliest
+	}
+	if eta.Latest.Before(eta.Expected) {
+		eta.Latest = eta.Expected
+	}
+	eta.Confidence = math.Round(eta.Confidence*100) / 100
+	return
+}
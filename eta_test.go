@@ -0,0 +1,134 @@
+package tracking51
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEstimator_Estimate(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeTestResponse(w, map[string][]TransitTime{
+			"success": {{
+				CourierCode:         "china-post",
+				OriginalCode:        "CN",
+				DestinationCode:     "US",
+				Delivered:           200,
+				Range1To7:           10,
+				Range8To15:          60,
+				Range16To30:         30,
+				AverageDeliveryTime: 12,
+			}},
+		})
+	})
+
+	now := time.Date(2022, 7, 10, 0, 0, 0, 0, time.Local)
+	estimator := NewEstimator(c.Services.Tracking, ETAOptions{})
+	estimator.now = func() time.Time { return now }
+	track := Track{
+		CourierCode: "china-post",
+		Original:    "CN",
+		Destination: "US",
+		OriginInfo:  Information{ReceivedDate: "2022-07-01 00:00:00"},
+	}
+	eta, err := estimator.Estimate(track)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eta.Source != "transit_time" || !eta.Expected.Equal(time.Date(2022, 7, 13, 0, 0, 0, 0, time.Local)) || eta.Earliest.Before(now) || !eta.Latest.After(eta.Expected) {
+		t.Errorf("unexpected eta: %#v", eta)
+	}
+	if eta.Confidence <= 0 || eta.Confidence >= 1 {
+		t.Errorf("unexpected confidence: %f", eta.Confidence)
+	}
+
+	// 时效数据有缓存
+	if _, err = estimator.Estimate(track); err != nil || requests != 1 {
+		t.Errorf("expected 1 request, got %d, error: %v", requests, err)
+	}
+
+	track.DestinationInfo.ArrivedDestinationDate = "2022-07-09 00:00:00"
+	if eta, _ = estimator.Estimate(track); !eta.Latest.Equal(time.Date(2022, 7, 12, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected eta after arrived destination: %#v", eta)
+	}
+
+	history := NewMemoryStore()
+	for i := 0; i < 20; i++ {
+		history.Save(Track{TrackingNumber: string(rune('A' + i)), CourierCode: "china-post", Original: "CN", Destination: "US", DeliveryStatus: StatusDelivered, TransitTime: 10 + i%5})
+	}
+	estimator = NewEstimator(c.Services.Tracking, ETAOptions{History: history})
+	estimator.now = func() time.Time { return now }
+	track.DestinationInfo.ArrivedDestinationDate = ""
+	if eta, _ = estimator.Estimate(track); eta.Source != "history" || !eta.Expected.Equal(time.Date(2022, 7, 13, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected eta from history: %#v", eta)
+	}
+
+	// 历史数据索引只建立一次，超过缓存时间或者调用 ReloadHistory 后重新建立
+	counting := &countingStore{Store: history}
+	estimator = NewEstimator(c.Services.Tracking, ETAOptions{History: counting, CacheTTL: time.Hour})
+	estimator.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		estimator.Estimate(track)
+	}
+	if counting.tracks != 1 {
+		t.Errorf("expected history to be read once, got %d", counting.tracks)
+	}
+	now = now.Add(time.Hour)
+	estimator.Estimate(track)
+	estimator.ReloadHistory()
+	if counting.tracks != 3 {
+		t.Errorf("expected history to be read 3 times, got %d", counting.tracks)
+	}
+}
+
+type countingStore struct {
+	Store
+	tracks int
+}
+
+func (s *countingStore) Tracks() ([]Track, error) {
+	s.tracks++
+	return s.Store.Tracks()
+}
+
+type blockingStore struct {
+	Store
+	reading chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Tracks() ([]Track, error) {
+	close(s.reading)
+	<-s.release
+	return s.Store.Tracks()
+}
+
+func TestEstimator_LoadHistoryUnlocked(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestResponse(w, map[string][]TransitTime{
+			"success": {{CourierCode: "usps", OriginalCode: "CN", DestinationCode: "US", Delivered: 10, Range8To15: 100}},
+		})
+	})
+	store := &blockingStore{Store: NewMemoryStore(), reading: make(chan struct{}), release: make(chan struct{})}
+	estimator := NewEstimator(c.Services.Tracking, ETAOptions{History: store})
+	go estimator.Estimate(Track{CourierCode: "usps", Original: "CN", Destination: "US", CreatedAt: "2022-07-01 00:00:00"})
+	<-store.reading
+
+	// 读取历史数据期间可以查询时效数据
+	done := make(chan error)
+	go func() {
+		_, _, err := estimator.TransitTime("usps", "CN", "US")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Error("TransitTime should not wait for loading history")
+	}
+	close(store.release)
+}
@@ -0,0 +1,399 @@
+package tracking51
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 包裹数据导出
+
+const defaultExportTimeLayout = "2006-01-02 15:04:05"
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	Columns     []string // 导出的列（为空时导出默认列），可选值参考 ExportColumns()
+	Checkpoints bool     // 是否按物流节点展开（每个物流节点导出为一行，按节点时间升序排列）
+	Lang        string   // 表头语言（cn, en），默认为 cn
+	TimeLayout  string   // 日期格式，默认为 2006-01-02 15:04:05（JSON Lines 默认为 RFC3339）
+}
+
+// 导出行中的物流节点
+type exportCheckpoint struct {
+	Source string // 节点来源（origin, destination）
+	TrackInformation
+}
+
+type exportColumn struct {
+	key        string
+	cn         string
+	en         string
+	date       bool // 是否为日期
+	checkpoint bool // 是否为物流节点列
+	value      func(t Track, c *exportCheckpoint) string
+}
+
+func informationColumns(prefix, cnPrefix, enPrefix string, info func(t Track) Information) []exportColumn {
+	return []exportColumn{
+		{key: prefix + "_courier_code", cn: cnPrefix + "物流商简码", en: enPrefix + " Courier Code", value: func(t Track, _ *exportCheckpoint) string { return info(t).CourierCode }},
+		{key: prefix + "_received_date", cn: cnPrefix + "上网时间", en: enPrefix + " Received Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return info(t).ReceivedDate }},
+		{key: prefix + "_dispatched_date", cn: cnPrefix + "封发时间", en: enPrefix + " Dispatched Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return info(t).DispatchedDate }},
+		{key: prefix + "_departed_airport_date", cn: cnPrefix + "离开机场时间", en: enPrefix + " Departed Airport Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return info(t).DepartedAirportDate }},
+		{key: prefix + "_arrived_abroad_date", cn: cnPrefix + "到达目的国时间", en: enPrefix + " Arrived Abroad Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return info(t).ArrivedAbroadDate }},
+		{key: prefix + "_customs_received_date", cn: cnPrefix + "移交海关时间", en: enPrefix + " Customs Received Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return info(t).CustomsReceivedDate }},
+		{key: prefix + "_arrived_destination_date", cn: cnPrefix + "到达目的城市时间", en: enPrefix + " Arrived Destination Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return info(t).ArrivedDestinationDate }},
+	}
+}
+
+func checkpointValue(fn func(c *exportCheckpoint) string) func(t Track, c *exportCheckpoint) string {
+	return func(_ Track, c *exportCheckpoint) string {
+		if c == nil {
+			return ""
+		}
+		return fn(c)
+	}
+}
+
+var exportColumns = func() []exportColumn {
+	columns := []exportColumn{
+		{key: "tracking_number", cn: "物流单号", en: "Tracking Number", value: func(t Track, _ *exportCheckpoint) string { return t.TrackingNumber }},
+		{key: "courier_code", cn: "物流商简码", en: "Courier Code", value: func(t Track, _ *exportCheckpoint) string { return t.CourierCode }},
+		{key: "order_number", cn: "订单号", en: "Order Number", value: func(t Track, _ *exportCheckpoint) string { return t.OrderNumber }},
+		{key: "title", cn: "包裹名称", en: "Title", value: func(t Track, _ *exportCheckpoint) string { return t.Title }},
+		{key: "delivery_status", cn: "包裹状态", en: "Delivery Status", value: func(t Track, _ *exportCheckpoint) string { return t.DeliveryStatus }},
+		{key: "substatus", cn: "包裹子状态", en: "Sub Status", value: func(t Track, _ *exportCheckpoint) string { return t.SubStatus }},
+		{key: "logistics_channel", cn: "物流渠道", en: "Logistics Channel", value: func(t Track, _ *exportCheckpoint) string { return t.LogisticsChannel }},
+		{key: "original", cn: "发件国", en: "Original", value: func(t Track, _ *exportCheckpoint) string { return t.Original }},
+		{key: "destination", cn: "目的国简码", en: "Destination", value: func(t Track, _ *exportCheckpoint) string { return t.Destination }},
+		{key: "destination_country", cn: "目的国", en: "Destination Country", value: func(t Track, _ *exportCheckpoint) string { return t.DestinationCountry }},
+		{key: "consignee", cn: "签收人", en: "Consignee", value: func(t Track, _ *exportCheckpoint) string { return t.Consignee }},
+		{key: "customer_name", cn: "客户姓名", en: "Customer Name", value: func(t Track, _ *exportCheckpoint) string { return t.CustomerName }},
+		{key: "customer_email", cn: "客户邮箱", en: "Customer Email", value: func(t Track, _ *exportCheckpoint) string { return t.CustomerEmail }},
+		{key: "customer_phone", cn: "客户手机号码", en: "Customer Phone", value: func(t Track, _ *exportCheckpoint) string { return t.CustomerPhone }},
+		{key: "transit_time", cn: "运输时长（天）", en: "Transit Time (Days)", value: func(t Track, _ *exportCheckpoint) string { return strconv.Itoa(t.TransitTime) }},
+		{key: "stay_time", cn: "未更新时长（天）", en: "Stay Time (Days)", value: func(t Track, _ *exportCheckpoint) string { return strconv.Itoa(t.StayTime) }},
+		{key: "weight", cn: "重量", en: "Weight", value: func(t Track, _ *exportCheckpoint) string { return t.Weight }},
+		{key: "status_info", cn: "最新物流信息", en: "Status Info", value: func(t Track, _ *exportCheckpoint) string { return t.StatusInfo }},
+		{key: "latest_event", cn: "最新物流梗概", en: "Latest Event", value: func(t Track, _ *exportCheckpoint) string { return t.LatestEvent }},
+		{key: "latest_checkpoint_time", cn: "最新物流时间", en: "Latest Checkpoint Time", date: true, value: func(t Track, _ *exportCheckpoint) string { return t.LatestCheckpointTime }},
+		{key: "order_create_time", cn: "发货时间", en: "Order Create Time", date: true, value: func(t Track, _ *exportCheckpoint) string { return t.OrderCreateTime }},
+		{key: "created_at", cn: "创建时间", en: "Created At", date: true, value: func(t Track, _ *exportCheckpoint) string { return t.CreatedAt }},
+		{key: "update_date", cn: "更新时间", en: "Update Date", date: true, value: func(t Track, _ *exportCheckpoint) string { return t.UpdateDate }},
+		{key: "destination_track_number", cn: "目的国单号", en: "Destination Track Number", value: func(t Track, _ *exportCheckpoint) string { return t.DestinationTrackNumber }},
+		{key: "exchange_number", cn: "中转单号", en: "Exchange Number", value: func(t Track, _ *exportCheckpoint) string { return t.ExchangeNumber }},
+		{key: "service_code", cn: "快递服务类型", en: "Service Code", value: func(t Track, _ *exportCheckpoint) string { return t.ServiceCode }},
+		{key: "archived", cn: "是否归档", en: "Archived", value: func(t Track, _ *exportCheckpoint) string { return strconv.FormatBool(t.Archived) }},
+		{key: "updating", cn: "是否更新中", en: "Updating", value: func(t Track, _ *exportCheckpoint) string { return strconv.FormatBool(t.Updating) }},
+		{key: "note", cn: "备注", en: "Note", value: func(t Track, _ *exportCheckpoint) string { return t.Note }},
+	}
+	columns = append(columns, informationColumns("origin", "发件国", "Origin", func(t Track) Information { return t.OriginInfo })...)
+	columns = append(columns, informationColumns("destination", "目的国", "Destination", func(t Track) Information { return t.DestinationInfo })...)
+	columns = append(columns,
+		exportColumn{key: "checkpoint_source", cn: "节点来源", en: "Checkpoint Source", checkpoint: true, value: checkpointValue(func(c *exportCheckpoint) string { return c.Source })},
+		exportColumn{key: "checkpoint_date", cn: "节点时间", en: "Checkpoint Date", date: true, checkpoint: true, value: checkpointValue(func(c *exportCheckpoint) string { return c.CheckpointDate })},
+		exportColumn{key: "checkpoint_status", cn: "节点状态", en: "Checkpoint Status", checkpoint: true, value: checkpointValue(func(c *exportCheckpoint) string { return c.CheckpointDeliveryStatus })},
+		exportColumn{key: "checkpoint_substatus", cn: "节点子状态", en: "Checkpoint Sub Status", checkpoint: true, value: checkpointValue(func(c *exportCheckpoint) string { return c.CheckpointDeliverySubStatus })},
+		exportColumn{key: "checkpoint_location", cn: "节点地址", en: "Checkpoint Location", checkpoint: true, value: checkpointValue(func(c *exportCheckpoint) string { return c.Location })},
+		exportColumn{key: "checkpoint_detail", cn: "节点详情", en: "Checkpoint Detail", checkpoint: true, value: checkpointValue(func(c *exportCheckpoint) string { return c.TrackingDetail })},
+	)
+	return columns
+}()
+
+// ExportColumns 返回所有可导出的列
+func ExportColumns() []string {
+	keys := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		keys[i] = column.key
+	}
+	return keys
+}
+
+type exporter struct {
+	options ExportOptions
+	columns []exportColumn
+}
+
+func newExporter(options ExportOptions, defaultTimeLayout string) (*exporter, error) {
+	if options.TimeLayout == "" {
+		options.TimeLayout = defaultTimeLayout
+	}
+	options.Lang = strings.ToLower(options.Lang)
+	e := &exporter{options: options}
+	if len(options.Columns) == 0 {
+		for _, column := range exportColumns {
+			if !column.checkpoint || options.Checkpoints {
+				e.columns = append(e.columns, column)
+			}
+		}
+		return e, nil
+	}
+
+	for _, key := range options.Columns {
+		found := false
+		for _, column := range exportColumns {
+			if column.key == key {
+				e.columns = append(e.columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, languageErrorf(options.Lang, "export_column_invalid", map[string]interface{}{"column": key})
+		}
+	}
+	return e, nil
+}
+
+func (e *exporter) keys() []string {
+	keys := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		keys[i] = column.key
+	}
+	return keys
+}
+
+func (e *exporter) headers() []string {
+	headers := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		if e.options.Lang == EnglishLanguage {
+			headers[i] = column.en
+		} else {
+			headers[i] = column.cn
+		}
+	}
+	return headers
+}
+
+// 导出的单元格
+type exportCell struct {
+	text string    // 文本（日期按 TimeLayout 格式化）
+	date time.Time // 日期列的值（无法解析时为零值）
+}
+
+func (e *exporter) row(t Track, c *exportCheckpoint) []exportCell {
+	cells := make([]exportCell, len(e.columns))
+	for i, column := range e.columns {
+		cells[i].text = column.value(t, c)
+		if column.date {
+			if d, ok := parseTime(cells[i].text); ok {
+				cells[i] = exportCell{text: d.Format(e.options.TimeLayout), date: d}
+			}
+		}
+	}
+	return cells
+}
+
+func exportTexts(cells []exportCell) []string {
+	texts := make([]string, len(cells))
+	for i, cell := range cells {
+		texts[i] = cell.text
+	}
+	return texts
+}
+
+// each 读取所有包裹并逐行回调
+func (e *exporter) each(reader TrackReader, fn func(cells []exportCell) error) error {
+	for {
+		track, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if !e.options.Checkpoints {
+			if err = fn(e.row(track, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		checkpoints := make([]exportCheckpoint, 0, len(track.OriginInfo.TrackInfo)+len(track.DestinationInfo.TrackInfo))
+		for _, ti := range track.OriginInfo.TrackInfo {
+			checkpoints = append(checkpoints, exportCheckpoint{Source: "origin", TrackInformation: ti})
+		}
+		for _, ti := range track.DestinationInfo.TrackInfo {
+			checkpoints = append(checkpoints, exportCheckpoint{Source: "destination", TrackInformation: ti})
+		}
+		sort.SliceStable(checkpoints, func(i, j int) bool {
+			ti, _ := parseTime(checkpoints[i].CheckpointDate)
+			tj, _ := parseTime(checkpoints[j].CheckpointDate)
+			return ti.Before(tj)
+		})
+		if len(checkpoints) == 0 {
+			if err = fn(e.row(track, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+		for i := range checkpoints {
+			if err = fn(e.row(track, &checkpoints[i])); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExportCSV 导出为 CSV 格式
+func ExportCSV(w io.Writer, reader TrackReader, options ExportOptions) error {
+	e, err := newExporter(options, defaultExportTimeLayout)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err = cw.Write(e.headers()); err != nil {
+		return err
+	}
+	if err = e.each(reader, func(cells []exportCell) error {
+		return cw.Write(exportTexts(cells))
+	}); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportJSONL 导出为 JSON Lines 格式（每行一个 JSON 对象，键名为列名，键的顺序与列的顺序相同）
+func ExportJSONL(w io.Writer, reader TrackReader, options ExportOptions) error {
+	e, err := newExporter(options, "2006-01-02T15:04:05Z07:00")
+	if err != nil {
+		return err
+	}
+
+	keys := e.keys()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	return e.each(reader, func(cells []exportCell) error {
+		buf.Reset()
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			// Encode 会在末尾添加换行符
+			if err := encoder.Encode(key); err != nil {
+				return err
+			}
+			buf.Truncate(buf.Len() - 1)
+			buf.WriteByte(':')
+			if err := encoder.Encode(cells[i].text); err != nil {
+				return err
+			}
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteString("}\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Tracks" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+	// 样式 1 为日期格式
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs></styleSheet>`
+	// 设置列宽，避免日期显示为 ####
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><cols><col min="1" max="{{max}}" width="20" customWidth="1"/></cols><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// Excel 日期序列号的起始时间
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// 日期转换为 Excel 日期序列号（使用日期所在时区的时间）
+func xlsxDateSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(xlsxEpoch).Hours() / 24
+}
+
+// 写入 XLSX 行（日期为使用日期格式的数字，其他为内联字符串）
+func writeXLSXRow(w io.Writer, cells []exportCell) error {
+	if _, err := io.WriteString(w, "<row>"); err != nil {
+		return err
+	}
+	for _, cell := range cells {
+		if !cell.date.IsZero() {
+			if _, err := io.WriteString(w, `<c s="1"><v>`+strconv.FormatFloat(xlsxDateSerial(cell.date), 'f', -1, 64)+"</v></c>"); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.WriteString(w, `<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(w, []byte(cell.text)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "</t></is></c>"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "</row>")
+	return err
+}
+
+// ExportXLSX 导出为 XLSX 格式（单个工作表，日期列为日期格式的单元格，不使用 TimeLayout）
+func ExportXLSX(w io.Writer, reader TrackReader, options ExportOptions) error {
+	e, err := newExporter(options, defaultExportTimeLayout)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(fw, file.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(sheet, strings.Replace(xlsxSheetHeader, "{{max}}", strconv.Itoa(len(e.columns)), 1)); err != nil {
+		return err
+	}
+	headers := make([]exportCell, len(e.columns))
+	for i, header := range e.headers() {
+		headers[i].text = header
+	}
+	if err = writeXLSXRow(sheet, headers); err != nil {
+		return err
+	}
+	if err = e.each(reader, func(cells []exportCell) error {
+		return writeXLSXRow(sheet, cells)
+	}); err != nil {
+		return err
+	}
+	if _, err = io.WriteString(sheet, xlsxSheetFooter); err != nil {
+		return err
+	}
+	return zw.Close()
+}
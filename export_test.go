@@ -0,0 +1,112 @@
+package tracking51
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportTracks = []Track{
+	{
+		TrackingNumber: "LX123456789CN",
+		CourierCode:    "china-post",
+		DeliveryStatus: StatusTransit,
+		CreatedAt:      "2022-07-01T10:00:00+08:00",
+		OriginInfo: Information{
+			TrackInfo: []TrackInformation{
+				{CheckpointDate: "2022-07-03 08:00:00", TrackingDetail: "Departure", Location: "Shenzhen"},
+				{CheckpointDate: "2022-07-02 08:00:00", TrackingDetail: "Posting", Location: "Shenzhen"},
+			},
+		},
+		DestinationInfo: Information{
+			TrackInfo: []TrackInformation{
+				{CheckpointDate: "2022-07-10 08:00:00", TrackingDetail: "Arrived", Location: "Los Angeles"},
+			},
+		},
+	},
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := ExportCSV(&buf, NewSliceTrackReader(exportTracks), ExportOptions{
+		Columns: []string{"tracking_number", "created_at", "checkpoint_detail"},
+		Lang:    EnglishLanguage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := csv.NewReader(&buf).ReadAll()
+	if len(rows) != 2 || rows[0][0] != "Tracking Number" || rows[1][2] != "" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	buf.Reset()
+	err = ExportCSV(&buf, NewSliceTrackReader(exportTracks), ExportOptions{Checkpoints: true, Columns: []string{"checkpoint_date", "checkpoint_detail"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ = csv.NewReader(&buf).ReadAll()
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+	// 按节点时间升序排列
+	for i, detail := range []string{"Posting", "Departure", "Arrived"} {
+		if rows[i+1][1] != detail {
+			t.Errorf("row %d: expected %s, got %v", i+1, detail, rows[i+1])
+		}
+	}
+
+	if err = ExportCSV(&buf, NewSliceTrackReader(exportTracks), ExportOptions{Columns: []string{"unknown"}}); err == nil {
+		t.Error("expected unknown column error")
+	}
+}
+
+func TestExportJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	err := ExportJSONL(&buf, NewSliceTrackReader(exportTracks), ExportOptions{
+		Columns:    []string{"tracking_number", "created_at", "title"},
+		TimeLayout: "2006-01-02",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.TrimSpace(buf.String()); s != `{"tracking_number":"LX123456789CN","created_at":"2022-07-01","title":""}` {
+		t.Errorf("unexpected output: %s", s)
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportXLSX(&buf, NewSliceTrackReader(exportTracks), ExportOptions{Checkpoints: true}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 6 {
+		t.Errorf("expected 6 files, got %d", len(r.File))
+	}
+	for _, f := range r.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		// 2022-07-01 10:00:00 的日期序列号
+		if !strings.Contains(string(b), `<c s="1"><v>44743.416666666664</v></c>`) {
+			t.Errorf("expected numeric date cell, got %s", b)
+		}
+	}
+}
+
+func TestXLSXDateSerial(t *testing.T) {
+	if v := xlsxDateSerial(time.Date(2022, 7, 1, 12, 0, 0, 0, time.FixedZone("CST", 8*3600))); v != 44743.5 {
+		t.Errorf("expected 44743.5, got %v", v)
+	}
+}
@@ -0,0 +1,364 @@
+package tracking51
+
+import (
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"strings"
+	"sync"
+)
+
+// 消息目录
+//
+// 验证错误和 API 错误使用固定的消息标识（验证错误通过 validation.ErrorObject.Code() 获取，API 错误通过 APIError.ID 获取），
+// 消息内容根据配置中的语言（Language）返回，支持使用 {{.name}} 格式的参数。
+
+var (
+	messagesMu sync.RWMutex
+	messages   = map[string]map[string]string{
+		ChineseLanguage: {
+			// 请求数据验证
+			"tracking_number_required":                   "包裹物流单号不能为空",
+			"courier_code_required":                      "物流商简码不能为空",
+			"customer_email_invalid":                     "客户邮箱地址格式错误",
+			"customer_phone_invalid":                     "客户手机号码格式错误",
+			"shipping_date_invalid":                      "包裹发货时间格式错误",
+			"tracking_shipping_date_invalid":             "跟踪包裹发货时间格式错误",
+			"original_code_required":                     "发件国二字简码不能为空",
+			"original_code_invalid":                      "无效的发件国二字简码",
+			"destination_code_required":                  "目的国二字简码不能为空",
+			"destination_code_invalid":                   "无效的目的国二字简码",
+			"courier_requires_tracking_shipping_date":    "物流商 {{.courier}} 需要提供包裹发货时间",
+			"courier_requires_tracking_postal_code":      "物流商 {{.courier}} 需要提供收件人所在地邮编",
+			"courier_requires_tracking_destination_code": "物流商 {{.courier}} 需要提供目的国二字简码",
+			"courier_requires_tracking_courier_account":  "物流商 {{.courier}} 需要提供物流商官方账号",
+			"timestamp_invalid":                          "无效的时间戳值",
+			"tracking_numbers_invalid":                   "无效的查询单号：{{.value}}",
+			"tracking_numbers_too_many":                  "查询单号不能超过 40 个",
+			"order_numbers_invalid":                      "无效的订单号：{{.value}}",
+			"order_numbers_too_many":                     "订单号不能超过 40 个",
+			"delivery_status_invalid":                    "无效的发货状态",
+			"archived_status_invalid":                    "无效的归档状态",
+			"created_date_min_required":                  "创建查询开始时间不能为空",
+			"created_date_max_required":                  "创建查询结束时间不能为空",
+			"created_date_max_too_small":                 "创建查询结束时间不能小于开始时间",
+			"shipping_date_min_required":                 "发货开始时间不能为空",
+			"shipping_date_max_required":                 "发货结束时间不能为空",
+			"shipping_date_max_too_small":                "发货结束时间不能小于开始时间",
+			"updated_date_min_required":                  "查询更新开始时间不能为空",
+			"updated_date_max_required":                  "查询更新结束时间不能为空",
+			"updated_date_max_too_small":                 "查询更新结束时间不能小于开始时间",
+			"lang_invalid":                               "无效的查询结果语言",
+			"postal_code_required":                       "偏远地区邮编不能为空",
+			"awb_number_invalid":                         "航空运单号必须为 11 位数字",
+			"awb_number_check_digit_invalid":             "航空运单号校验位错误",
+			"batch_empty":                                "请求数据不能为空",
+			"batch_too_many":                             "请求数据不能超过 {{.max}} 个",
+			"batch_item":                                 "第 {{.index}} 个：{{.messages}}",
+			"batch_item_tracking_number":                 "第 {{.index}} 个单号 {{.tracking_number}}：{{.messages}}",
+			"days_negative":                              "天数不能小于 0",
+			"time_range_invalid":                         "结束时间不能小于开始时间",
+			"phone_format_invalid":                       "电话号码必须为 + 国际电话区号 + 号码的格式",
+			"phone_calling_code_invalid":                 "无效的国际电话区号",
+			"phone_length_invalid":                       "电话号码长度错误（国际电话区号：+{{.calling_code}}）",
+			"phone_digits_only":                          "电话号码只能包含数字",
+			"country_code_invalid":                       "无效的国家二字简码：{{.value}}",
+			"requirement_field_invalid":                  "无效的字段：{{.field}}",
+			"courier_requirement_field_invalid":          "{{.courier}}: 无效的字段：{{.field}}",
+			"export_column_invalid":                      "无效的导出列：{{.column}}",
+			"eta_start_time_missing":                     "缺少包裹的发货时间",
+			"eta_transit_time_missing":                   "没有该线路的时效数据",
+			"realtime_quota_exceeded":                    "实时查询次数已用完",
+			"webhook_signature_invalid":                  "无效的推送签名",
+			"webhook_expired":                            "推送时间已超出有效期",
+			"batcher_closed":                             "批量请求聚合器已关闭",
+			"batch_no_result":                            "没有返回该单号的结果",
+			"batch_conflict":                             "该单号已有不同数据的请求等待发送",
+			"courier_correction_state_invalid":           "无效的物流商纠正状态：{{.tracking_number}}",
+			"courier_correction_courier_changed":         "包裹的物流商已被修改为 {{.courier}}，放弃纠正",
+			"courier_correction_track_missing":           "超过等待时间仍然查询不到该包裹",
+			// 物流商额外字段
+			"requirement_tracking_shipping_date":    "包裹发货时间",
+			"requirement_tracking_postal_code":      "收件人所在地邮编",
+			"requirement_tracking_destination_code": "目的国二字简码",
+			"requirement_tracking_courier_account":  "物流商官方账号",
+			// 告警
+			"alert_stay_time":        "物流信息已 {{.days}} 天未更新（阈值 {{.threshold}} 天）",
+			"alert_status":           "包裹状态为{{.status}}",
+			"alert_return_to_sender": "包裹被退回（{{.keyword}}：{{.detail}}）",
+			"alert_customs_hold":     "包裹被海关扣留（{{.keyword}}：{{.detail}}）",
+			"alert_no_first_scan":    "发货 {{.days}} 天后仍然没有物流信息（阈值 {{.threshold}} 天）",
+			// 手动更新检查
+			"refresh_notfound_stopped":   "查询不到超过 15 天，已经停止更新，可以手动更新",
+			"refresh_delivered_stopped":  "已经签收，并且已经停止更新，可以手动更新",
+			"refresh_aged_stopped":       "添加时间超过 30 天，已经停止更新，可以手动更新",
+			"refresh_updating":           "单号仍在自动更新，无需手动更新",
+			"refresh_notfound_too_new":   "查询不到未超过 15 天，暂时不能手动更新",
+			"refresh_too_new":            "添加时间未超过 30 天，暂时不能手动更新",
+			"refresh_created_at_unknown": "无法获取单号的添加时间，不能手动更新",
+			// API 错误
+			"payment_required":           "API 服务只提供给付费账户，请付费购买单号以解锁 API 服务",
+			"bad_request":                "请求类型错误",
+			"unauthorized":               "授权失败或没有权限，请检查并确保你 API Key 正确无误",
+			"not_found":                  "请求的资源不存在",
+			"timeout":                    "请求超时",
+			"parameters_too_long":        "请求参数长度超过限制",
+			"parameters_format_invalid":  "请求参数格式不合要求",
+			"parameters_exceeded_limit":  "请求参数数量超过限制",
+			"parameters_missing":         "缺少请求参数或者请求参数无法解析",
+			"parameters_invalid":         "部分必填参数为空",
+			"courier_code_invalid":       "物流商简码无法识别或者不支持该物流商",
+			"tracking_number_exists":     "跟踪单号已存在，无需再次创建",
+			"tracking_number_not_exists": "跟踪单号不存在",
+			"too_many_requests":          "API 请求频率次数限制，请稍后再试",
+			"internal_error":             "系统错误",
+		},
+		EnglishLanguage: {
+			"tracking_number_required":                   "Tracking number is required",
+			"courier_code_required":                      "Courier code is required",
+			"customer_email_invalid":                     "Invalid customer email",
+			"customer_phone_invalid":                     "Invalid customer phone number",
+			"shipping_date_invalid":                      "Invalid shipping date",
+			"tracking_shipping_date_invalid":             "Invalid tracking shipping date",
+			"original_code_required":                     "Origin country code is required",
+			"original_code_invalid":                      "Invalid origin country code",
+			"destination_code_required":                  "Destination country code is required",
+			"destination_code_invalid":                   "Invalid destination country code",
+			"courier_requires_tracking_shipping_date":    "Courier {{.courier}} requires the shipping date",
+			"courier_requires_tracking_postal_code":      "Courier {{.courier}} requires the recipient postal code",
+			"courier_requires_tracking_destination_code": "Courier {{.courier}} requires the destination country code",
+			"courier_requires_tracking_courier_account":  "Courier {{.courier}} requires the courier account",
+			"timestamp_invalid":                          "Invalid timestamp",
+			"tracking_numbers_invalid":                   "Invalid tracking numbers: {{.value}}",
+			"tracking_numbers_too_many":                  "No more than 40 tracking numbers are allowed",
+			"order_numbers_invalid":                      "Invalid order numbers: {{.value}}",
+			"order_numbers_too_many":                     "No more than 40 order numbers are allowed",
+			"delivery_status_invalid":                    "Invalid delivery status",
+			"archived_status_invalid":                    "Invalid archived status",
+			"created_date_min_required":                  "Created date start is required",
+			"created_date_max_required":                  "Created date end is required",
+			"created_date_max_too_small":                 "Created date end must not be earlier than the start",
+			"shipping_date_min_required":                 "Shipping date start is required",
+			"shipping_date_max_required":                 "Shipping date end is required",
+			"shipping_date_max_too_small":                "Shipping date end must not be earlier than the start",
+			"updated_date_min_required":                  "Updated date start is required",
+			"updated_date_max_required":                  "Updated date end is required",
+			"updated_date_max_too_small":                 "Updated date end must not be earlier than the start",
+			"lang_invalid":                               "Invalid language",
+			"postal_code_required":                       "Postal code is required",
+			"awb_number_invalid":                         "AWB number must be 11 digits",
+			"awb_number_check_digit_invalid":             "Invalid AWB number check digit",
+			"batch_empty":                                "Request data is required",
+			"batch_too_many":                             "No more than {{.max}} items are allowed per request",
+			"batch_item":                                 "Item {{.index}}: {{.messages}}",
+			"batch_item_tracking_number":                 "Item {{.index}} ({{.tracking_number}}): {{.messages}}",
+			"days_negative":                              "Days must not be negative",
+			"time_range_invalid":                         "End time must not be earlier than the start time",
+			"phone_format_invalid":                       "Phone number must be in the format of + calling code + number",
+			"phone_calling_code_invalid":                 "Invalid calling code",
+			"phone_length_invalid":                       "Invalid phone number length (calling code: +{{.calling_code}})",
+			"phone_digits_only":                          "Phone number must contain digits only",
+			"country_code_invalid":                       "Invalid country code: {{.value}}",
+			"requirement_field_invalid":                  "Invalid field: {{.field}}",
+			"courier_requirement_field_invalid":          "{{.courier}}: invalid field: {{.field}}",
+			"export_column_invalid":                      "Invalid export column: {{.column}}",
+			"eta_start_time_missing":                     "The shipping time of the package is unknown",
+			"eta_transit_time_missing":                   "No transit time data for the lane",
+			"realtime_quota_exceeded":                    "The realtime query quota has been used up",
+			"webhook_signature_invalid":                  "Invalid webhook signature",
+			"webhook_expired":                            "The webhook timestamp is out of the valid window",
+			"batcher_closed":                             "The batcher is closed",
+			"batch_no_result":                            "No result was returned for the tracking number",
+			"batch_conflict":                             "A request with different data for the tracking number is waiting to be sent",
+			"courier_correction_state_invalid":           "Invalid courier correction state: {{.tracking_number}}",
+			"courier_correction_courier_changed":         "The courier has been changed to {{.courier}}, correction abandoned",
+			"courier_correction_track_missing":           "The package was not returned within the pending timeout",
+			// 物流商额外字段
+			"requirement_tracking_shipping_date":    "Shipping date",
+			"requirement_tracking_postal_code":      "Recipient postal code",
+			"requirement_tracking_destination_code": "Destination country code",
+			"requirement_tracking_courier_account":  "Courier account",
+			// 告警
+			"alert_stay_time":        "No tracking update for {{.days}} days (threshold: {{.threshold}} days)",
+			"alert_status":           "The package status is {{.status}}",
+			"alert_return_to_sender": "Returned to sender ({{.keyword}}: {{.detail}})",
+			"alert_customs_hold":     "Held by customs ({{.keyword}}: {{.detail}})",
+			"alert_no_first_scan":    "No tracking information {{.days}} days after shipping (threshold: {{.threshold}} days)",
+			// 手动更新检查
+			"refresh_notfound_stopped":   "Not found for more than 15 days and stopped updating, can be refreshed",
+			"refresh_delivered_stopped":  "Delivered and stopped updating, can be refreshed",
+			"refresh_aged_stopped":       "Added more than 30 days ago and stopped updating, can be refreshed",
+			"refresh_updating":           "Still updating automatically, no need to refresh",
+			"refresh_notfound_too_new":   "Not found for no more than 15 days, cannot be refreshed yet",
+			"refresh_too_new":            "Added no more than 30 days ago, cannot be refreshed yet",
+			"refresh_created_at_unknown": "Unknown created time, cannot be refreshed",
+			// API 错误
+			"payment_required":           "The API is only available to paid accounts",
+			"bad_request":                "Bad request",
+			"unauthorized":               "Unauthorized, please check your API key",
+			"not_found":                  "The requested resource does not exist",
+			"timeout":                    "Request timeout",
+			"parameters_too_long":        "Request parameters are too long",
+			"parameters_format_invalid":  "Invalid request parameter format",
+			"parameters_exceeded_limit":  "Too many request parameters",
+			"parameters_missing":         "Request parameters are missing or cannot be parsed",
+			"parameters_invalid":         "Some required parameters are empty",
+			"courier_code_invalid":       "The courier code is not recognized or not supported",
+			"tracking_number_exists":     "The tracking number already exists",
+			"tracking_number_not_exists": "The tracking number does not exist",
+			"too_many_requests":          "Too many requests, please try again later",
+			"internal_error":             "Internal error",
+		},
+	}
+)
+
+// RegisterMessages 添加或者覆盖语言的消息
+func RegisterMessages(lang string, items map[string]string) {
+	lang = strings.ToLower(lang)
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	if messages[lang] == nil {
+		messages[lang] = make(map[string]string, len(items))
+	}
+	for id, message := range items {
+		messages[lang][id] = message
+	}
+}
+
+func lookupMessage(lang, id string) (string, bool) {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	message, ok := messages[strings.ToLower(lang)][id]
+	return message, ok
+}
+
+// Message 返回消息标识对应的消息，没有该语言的消息时返回中文消息，都没有时返回消息标识
+func Message(lang, id string) string {
+	if message, ok := lookupMessage(lang, id); ok {
+		return message
+	}
+	if message, ok := lookupMessage(ChineseLanguage, id); ok {
+		return message
+	}
+	return id
+}
+
+// 创建验证错误（默认为中文消息）
+func messageError(id string) validation.ErrorObject {
+	return validation.NewError(id, Message(ChineseLanguage, id)).(validation.ErrorObject)
+}
+
+// 创建带参数的验证错误
+func messageErrorf(id string, params map[string]interface{}) validation.ErrorObject {
+	return messageError(id).SetParams(params).(validation.ErrorObject)
+}
+
+// 创建指定语言的错误
+func languageErrorf(lang, id string, params map[string]interface{}) error {
+	return TranslateError(messageErrorf(id, params), lang)
+}
+
+// 返回指定语言的带参数的消息
+func formatMessage(lang, id string, params map[string]interface{}) string {
+	return languageErrorf(lang, id, params).Error()
+}
+
+// MessageError 使用消息标识的错误（比如 ErrBatcherClosed）
+//
+// 翻译为其他语言后仍然可以使用 errors.Is 判断（根据消息标识比较）。
+type MessageError struct {
+	ID      string // 消息标识
+	Message string // 错误信息
+}
+
+func newMessageError(id string) *MessageError {
+	return &MessageError{ID: id, Message: Message(ChineseLanguage, id)}
+}
+
+func (e *MessageError) Error() string {
+	return e.Message
+}
+
+func (e *MessageError) Is(target error) bool {
+	t, ok := target.(*MessageError)
+	return ok && t.ID == e.ID
+}
+
+// TranslateError 将验证错误（包括 validation.Errors 和 *BatchError 中的错误）和 *MessageError 翻译为指定语言，其他错误原样返回
+func TranslateError(err error, lang string) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case validation.Errors:
+		errs := make(validation.Errors, len(e))
+		for field, fieldErr := range e {
+			errs[field] = TranslateError(fieldErr, lang)
+		}
+		return errs
+	case validation.ErrorObject:
+		if message, ok := lookupMessage(lang, e.Code()); ok {
+			return e.SetMessage(message)
+		}
+		return e
+	case *MessageError:
+		if message, ok := lookupMessage(lang, e.ID); ok && message != e.Message {
+			return &MessageError{ID: e.ID, Message: message}
+		}
+		return e
+	case *BatchError:
+		be := &BatchError{Items: make([]BatchItemError, len(e.Items))}
+		for i, item := range e.Items {
+			be.Items[i] = newBatchItemError(item.Index, item.TrackingNumber, TranslateError(item.err, lang))
+			be.Items[i].lang = lang
+		}
+		return be
+	}
+	return err
+}
+
+// 验证请求数据，并将错误翻译为指定语言
+func validate(v validation.Validatable, lang string) error {
+	return TranslateError(v.Validate(), lang)
+}
+
+// APIError 51Tracking 接口返回的错误
+type APIError struct {
+	Code    int    `json:"code"`    // 错误代码
+	ID      string `json:"id"`      // 消息标识（未知的错误代码为空）
+	Message string `json:"message"` // 错误信息
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// 错误代码 => 消息标识
+var apiErrorMessageIDs = map[int]string{
+	PaymentRequiredError:                "payment_required",
+	BadRequestError:                     "bad_request",
+	UnauthorizedError:                   "unauthorized",
+	NotFoundError:                       "not_found",
+	TimeOutError:                        "timeout",
+	RequestParametersTooLongError:       "parameters_too_long",
+	RequestParametersFormatError:        "parameters_format_invalid",
+	RequestParametersExceededLimitError: "parameters_exceeded_limit",
+	LostRequestParametersOrParseError:   "parameters_missing",
+	ParametersInvalidError:              "parameters_invalid",
+	CourierCodeInvalidError:             "courier_code_invalid",
+	TrackingNumberIsExistsError:         "tracking_number_exists",
+	TrackingNumberIsNotExistsError:      "tracking_number_not_exists",
+	TooManyRequestsError:                "too_many_requests",
+	InternalError:                       "internal_error",
+}
+
+// 根据错误代码返回指定语言的错误
+func errorWrap(code int, message, lang string) error {
+	if code == Success || code == NoContent {
+		return nil
+	}
+
+	e := &APIError{Code: code, ID: apiErrorMessageIDs[code], Message: message}
+	if e.ID != "" {
+		e.Message = Message(lang, e.ID)
+	}
+	return e
+}
@@ -0,0 +1,122 @@
+package tracking51
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hiscaler/51tracking-go/config"
+)
+
+func TestMessage(t *testing.T) {
+	if m := Message(EnglishLanguage, "tracking_number_required"); m != "Tracking number is required" {
+		t.Errorf("unexpected en message: %s", m)
+	}
+	if m := Message("fr", "tracking_number_required"); m != "包裹物流单号不能为空" {
+		t.Errorf("expected fallback to cn message, got %s", m)
+	}
+	if m := Message(EnglishLanguage, "unknown_id"); m != "unknown_id" {
+		t.Errorf("expected message id, got %s", m)
+	}
+
+	RegisterMessages("fr", map[string]string{"tracking_number_required": "Le numéro de suivi est requis"})
+	if m := Message("fr", "tracking_number_required"); m != "Le numéro de suivi est requis" {
+		t.Errorf("unexpected fr message: %s", m)
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	err := CreateTrackRequests{{TrackingNumber: "", CourierCode: "usps"}}.Validate()
+	en := TranslateError(err, EnglishLanguage)
+	var be *BatchError
+	if !errors.As(en, &be) || len(be.Items) != 1 {
+		t.Fatalf("expected *BatchError, got %v", en)
+	}
+	if m := be.Items[0].Fields["tracking_number"]; m != "Tracking number is required" {
+		t.Errorf("unexpected translated message: %s", m)
+	}
+	if s := be.Error(); s != "Item 1: tracking_number: Tracking number is required" {
+		t.Errorf("unexpected translated batch error: %s", s)
+	}
+	if s := err.Error(); s != "第 1 个：tracking_number: 包裹物流单号不能为空" {
+		t.Errorf("unexpected batch error: %s", s)
+	}
+
+	if s := TranslateError(ValidatePhone("+86138"), EnglishLanguage).Error(); s != "Invalid phone number length (calling code: +86)" {
+		t.Errorf("unexpected translated phone error: %s", s)
+	}
+
+	err = validateBatchSize(41, 40)
+	if s := TranslateError(err, EnglishLanguage).Error(); s != "No more than 40 items are allowed per request" {
+		t.Errorf("unexpected translated message: %s", s)
+	}
+}
+
+func TestMessageError(t *testing.T) {
+	err := TranslateError(ErrBatcherClosed, EnglishLanguage)
+	if !errors.Is(err, ErrBatcherClosed) || errors.Is(err, ErrBatchConflict) {
+		t.Errorf("unexpected error: %#v", err)
+	}
+	if err.Error() != "The batcher is closed" {
+		t.Errorf("expected english message, got %s", err.Error())
+	}
+	if TranslateError(ErrBatcherClosed, ChineseLanguage) != ErrBatcherClosed {
+		t.Error("expected the same error")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	for id := range messages[ChineseLanguage] {
+		if _, ok := messages[EnglishLanguage][id]; !ok {
+			t.Errorf("missing en message: %s", id)
+		}
+	}
+	for id := range messages[EnglishLanguage] {
+		if _, ok := messages[ChineseLanguage][id]; !ok {
+			t.Errorf("missing cn message: %s", id)
+		}
+	}
+}
+
+func TestErrorWrap(t *testing.T) {
+	err := errorWrap(TrackingNumberIsNotExistsError, "Tracking No. does not exist.", EnglishLanguage)
+	var e *APIError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if e.ID != "tracking_number_not_exists" || e.Message != "The tracking number does not exist" {
+		t.Errorf("unexpected error: %#v", e)
+	}
+
+	err = errorWrap(9999, "Unknown", EnglishLanguage)
+	if !errors.As(err, &e) || e.ID != "" || e.Message != "Unknown" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if errorWrap(Success, "Success", EnglishLanguage) != nil {
+		t.Error("expected nil error")
+	}
+}
+
+func TestClient_Language(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":424,"message":"Tracking No. does not exist.","data":[]}`))
+	}))
+	t.Cleanup(ts.Close)
+	c := NewTracking51(config.Config{AppKey: "test", Language: EnglishLanguage})
+	c.httpClient.SetBaseURL(ts.URL)
+
+	_, _, err := c.Services.Tracking.Refresh(RefreshTrackRequests{{}})
+	if err == nil || !strings.Contains(err.Error(), "Tracking number is required") {
+		t.Errorf("expected english validation error, got %v", err)
+	}
+
+	_, _, err = c.Services.Tracking.Delete(DeleteTrackRequests{{TrackingNumber: "RR123456789CN", CourierCode: "china-ems"}})
+	var e *APIError
+	if !errors.As(err, &e) || e.Message != "The tracking number does not exist" {
+		t.Errorf("expected english api error, got %v", err)
+	}
+}
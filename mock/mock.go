@@ -0,0 +1,150 @@
+// Package mock 提供 51Tracking 各个服务接口的模拟实现，用于单元测试
+//
+// 每个方法的调用都会被记录，返回值通过 XxxFunc 设置，没有设置时返回零值和 Err。
+package mock
+
+import (
+	tracking51 "github.com/hiscaler/51tracking-go"
+	"sync"
+)
+
+// Call 一次方法调用的记录
+type Call struct {
+	Method string        // 方法名
+	Args   []interface{} // 参数
+}
+
+// Recorder 记录方法调用
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls 返回所有调用记录
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsOf 返回指定方法的调用记录
+func (r *Recorder) CallsOf(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset 清空调用记录
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// AccountService 帐号服务
+type AccountService struct {
+	Recorder
+	Err         error
+	ProfileFunc func() (tracking51.AccountProfile, error)
+}
+
+var _ tracking51.AccountService = (*AccountService)(nil)
+
+func (m *AccountService) Profile() (profile tracking51.AccountProfile, err error) {
+	m.record("Profile")
+	if m.ProfileFunc != nil {
+		return m.ProfileFunc()
+	}
+	return profile, m.Err
+}
+
+// CourierService 物流商服务
+type CourierService struct {
+	Recorder
+	Err        error
+	ListFunc   func(lang string) ([]tracking51.Courier, error)
+	UpdateFunc func(trackingNumber, oldCourierCode, newCourierCode string) error
+}
+
+var _ tracking51.CourierService = (*CourierService)(nil)
+
+func (m *CourierService) List(lang string) (items []tracking51.Courier, err error) {
+	m.record("List", lang)
+	if m.ListFunc != nil {
+		return m.ListFunc(lang)
+	}
+	return items, m.Err
+}
+
+func (m *CourierService) Update(trackingNumber, oldCourierCode, newCourierCode string) error {
+	m.record("Update", trackingNumber, oldCourierCode, newCourierCode)
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(trackingNumber, oldCourierCode, newCourierCode)
+	}
+	return m.Err
+}
+
+// RealtimeService 实时查询服务
+type RealtimeService struct {
+	Recorder
+	Err       error
+	QueryFunc func(req tracking51.RealtimeRequest) (tracking51.Track, error)
+	QuotaFunc func() (used, remaining int)
+}
+
+var _ tracking51.RealtimeService = (*RealtimeService)(nil)
+
+func (m *RealtimeService) Query(req tracking51.RealtimeRequest) (track tracking51.Track, err error) {
+	m.record("Query", req)
+	if m.QueryFunc != nil {
+		return m.QueryFunc(req)
+	}
+	return track, m.Err
+}
+
+// Quota 没有设置 QuotaFunc 时返回不限制次数
+func (m *RealtimeService) Quota() (used, remaining int) {
+	m.record("Quota")
+	if m.QuotaFunc != nil {
+		return m.QuotaFunc()
+	}
+	return 0, -1
+}
+
+// Services 所有服务的模拟实现
+type Services struct {
+	Account  *AccountService
+	Courier  *CourierService
+	Tracking *TrackingService
+	Realtime *RealtimeService
+}
+
+// New 创建所有服务的模拟实现
+func New() *Services {
+	return &Services{
+		Account:  &AccountService{},
+		Courier:  &CourierService{},
+		Tracking: &TrackingService{},
+		Realtime: &RealtimeService{},
+	}
+}
+
+// Install 使用模拟实现替换客户端的所有服务
+func (s *Services) Install(client *tracking51.Tracking51) {
+	client.Services.Account = s.Account
+	client.Services.Courier = s.Courier
+	client.Services.Tracking = s.Tracking
+	client.Services.Realtime = s.Realtime
+}
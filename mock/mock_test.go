@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	tracking51 "github.com/hiscaler/51tracking-go"
+	"github.com/hiscaler/51tracking-go/config"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServices_Install(t *testing.T) {
+	client := tracking51.NewTracking51(config.Config{AppKey: "test"})
+	services := New()
+	services.Install(client)
+
+	services.Courier.Err = errors.New("courier error")
+	if err := client.Services.Courier.Update("A", "usps", "ups"); err != services.Courier.Err {
+		t.Errorf("expected courier error, got %v", err)
+	}
+	calls := services.Courier.CallsOf("Update")
+	if len(calls) != 1 || calls[0].Args[0] != "A" || calls[0].Args[2] != "ups" {
+		t.Errorf("unexpected calls: %#v", calls)
+	}
+
+	services.Courier.Reset()
+	if len(services.Courier.Calls()) != 0 {
+		t.Error("expected calls to be reset")
+	}
+}
+
+func TestTrackingService_RefreshScheduler(t *testing.T) {
+	created := time.Now().AddDate(0, 0, -40).Format("2006-01-02 15:04:05")
+	m := &TrackingService{
+		QueryFunc: func(params tracking51.TracksQueryParams) ([]tracking51.Track, bool, error) {
+			if params.DeliveryStatus != tracking51.StatusDelivered {
+				return nil, true, nil
+			}
+			return []tracking51.Track{{TrackingNumber: "A", CourierCode: "usps", DeliveryStatus: tracking51.StatusDelivered, CreatedAt: created}}, true, nil
+		},
+		RefreshFunc: func(req tracking51.RefreshTrackRequests) ([]tracking51.RefreshResultSuccess, []tracking51.RefreshResultError, error) {
+			return make([]tracking51.RefreshResultSuccess, len(req)), nil, nil
+		},
+	}
+
+	report, err := tracking51.NewRefreshScheduler(m, tracking51.RefreshSchedulerOptions{}).RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 1 || len(report.Success) != 1 {
+		t.Errorf("unexpected report: %#v", report)
+	}
+	if n := len(m.CallsOf("Query")); n != 3 {
+		t.Errorf("expected 3 queries, got %d", n)
+	}
+	calls := m.CallsOf("Refresh")
+	if len(calls) != 1 || calls[0].Args[0].(tracking51.RefreshTrackRequests)[0].TrackingNumber != "A" {
+		t.Errorf("unexpected refresh calls: %#v", calls)
+	}
+}
+
+func TestTrackingService_Options(t *testing.T) {
+	m := &TrackingService{Err: errors.New("query error")}
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tracking51.NewRefreshScheduler(m, tracking51.RefreshSchedulerOptions{Logger: log.New(&buf, "", 0)}).Run(ctx)
+	if !strings.Contains(buf.String(), "query error") {
+		t.Errorf("expected error to be written to the configured logger, got %q", buf.String())
+	}
+
+	_, err := tracking51.NewEstimator(m, tracking51.ETAOptions{Language: tracking51.EnglishLanguage}).Estimate(tracking51.Track{})
+	if err == nil || err.Error() != tracking51.Message(tracking51.EnglishLanguage, "eta_start_time_missing") {
+		t.Errorf("expected english error, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package mock
+
+import (
+	tracking51 "github.com/hiscaler/51tracking-go"
+)
+
+// TrackingService 物流单号服务
+type TrackingService struct {
+	Recorder
+	Err                 error
+	CreateFunc          func(req tracking51.CreateTrackRequest) ([]tracking51.CreateResult, []tracking51.CreateResult, error)
+	BatchCreateFunc     func(req tracking51.CreateTrackRequests) ([]tracking51.CreateResult, []tracking51.CreateResult, error)
+	UpdateFunc          func(req tracking51.UpdateTrackRequest) ([]tracking51.UpdateResult, []tracking51.UpdateResult, error)
+	QueryFunc           func(params tracking51.TracksQueryParams) ([]tracking51.Track, bool, error)
+	ReaderFunc          func(params tracking51.TracksQueryParams) tracking51.TrackReader
+	DeleteFunc          func(req tracking51.DeleteTrackRequests) ([]tracking51.DeleteTrackResult, []tracking51.DeleteTrackResult, error)
+	StopUpdateFunc      func(req tracking51.StopUpdateTrackRequests) ([]tracking51.StopUpdateResultSuccess, []tracking51.StopUpdateResultError, error)
+	ArchiveFunc         func(req tracking51.ArchiveTrackRequests) ([]tracking51.ArchiveResultSuccess, []tracking51.ArchiveResultError, error)
+	UnarchiveFunc       func(req tracking51.ArchiveTrackRequests) ([]tracking51.ArchiveResultSuccess, []tracking51.ArchiveResultError, error)
+	RefreshFunc         func(req tracking51.RefreshTrackRequests) ([]tracking51.RefreshResultSuccess, []tracking51.RefreshResultError, error)
+	StatusStatisticFunc func(req tracking51.StatusStatisticRequest) (tracking51.StatusStatistic, error)
+	TransitTimeFunc     func(req tracking51.TransitTimeRequests) ([]tracking51.TransitTime, []tracking51.TransitTime, error)
+	RemoteDetectionFunc func(req tracking51.RemoteDetectionRequest) (tracking51.RemoteDetectionResult, error)
+}
+
+var _ tracking51.TrackingService = (*TrackingService)(nil)
+
+func (m *TrackingService) Create(req tracking51.CreateTrackRequest) (success []tracking51.CreateResult, error []tracking51.CreateResult, err error) {
+	m.record("Create", req)
+	if m.CreateFunc != nil {
+		return m.CreateFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) BatchCreate(req tracking51.CreateTrackRequests) (success []tracking51.CreateResult, error []tracking51.CreateResult, err error) {
+	m.record("BatchCreate", req)
+	if m.BatchCreateFunc != nil {
+		return m.BatchCreateFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) Update(req tracking51.UpdateTrackRequest) (success []tracking51.UpdateResult, error []tracking51.UpdateResult, err error) {
+	m.record("Update", req)
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+// Query 没有设置 QueryFunc 时返回最后一页的空数据
+func (m *TrackingService) Query(params tracking51.TracksQueryParams) (items []tracking51.Track, isLastPage bool, err error) {
+	m.record("Query", params)
+	if m.QueryFunc != nil {
+		return m.QueryFunc(params)
+	}
+	return nil, true, m.Err
+}
+
+// Reader 没有设置 ReaderFunc 时通过 Query 逐页读取数据
+func (m *TrackingService) Reader(params tracking51.TracksQueryParams) tracking51.TrackReader {
+	m.record("Reader", params)
+	if m.ReaderFunc != nil {
+		return m.ReaderFunc(params)
+	}
+	return tracking51.NewQueryTrackReader(m, params)
+}
+
+func (m *TrackingService) Delete(req tracking51.DeleteTrackRequests) (success []tracking51.DeleteTrackResult, error []tracking51.DeleteTrackResult, err error) {
+	m.record("Delete", req)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) StopUpdate(req tracking51.StopUpdateTrackRequests) (success []tracking51.StopUpdateResultSuccess, error []tracking51.StopUpdateResultError, err error) {
+	m.record("StopUpdate", req)
+	if m.StopUpdateFunc != nil {
+		return m.StopUpdateFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) Archive(req tracking51.ArchiveTrackRequests) (success []tracking51.ArchiveResultSuccess, error []tracking51.ArchiveResultError, err error) {
+	m.record("Archive", req)
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) Unarchive(req tracking51.ArchiveTrackRequests) (success []tracking51.ArchiveResultSuccess, error []tracking51.ArchiveResultError, err error) {
+	m.record("Unarchive", req)
+	if m.UnarchiveFunc != nil {
+		return m.UnarchiveFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) Refresh(req tracking51.RefreshTrackRequests) (success []tracking51.RefreshResultSuccess, error []tracking51.RefreshResultError, err error) {
+	m.record("Refresh", req)
+	if m.RefreshFunc != nil {
+		return m.RefreshFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) StatusStatistic(req tracking51.StatusStatisticRequest) (stat tracking51.StatusStatistic, err error) {
+	m.record("StatusStatistic", req)
+	if m.StatusStatisticFunc != nil {
+		return m.StatusStatisticFunc(req)
+	}
+	return stat, m.Err
+}
+
+func (m *TrackingService) TransitTime(req tracking51.TransitTimeRequests) (success []tracking51.TransitTime, error []tracking51.TransitTime, err error) {
+	m.record("TransitTime", req)
+	if m.TransitTimeFunc != nil {
+		return m.TransitTimeFunc(req)
+	}
+	return nil, nil, m.Err
+}
+
+func (m *TrackingService) RemoteDetection(req tracking51.RemoteDetectionRequest) (item tracking51.RemoteDetectionResult, err error) {
+	m.record("RemoteDetection", req)
+	if m.RemoteDetectionFunc != nil {
+		return m.RemoteDetectionFunc(req)
+	}
+	return item, m.Err
+}
@@ -0,0 +1,147 @@
+package tracking51
+
+import (
+	"encoding/json"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"sync"
+	"time"
+)
+
+// 实时查询
+
+var ErrRealtimeQuotaExceeded = newMessageError("realtime_quota_exceeded")
+
+// 实时查询的频率和次数限制（所有副本共享）
+//
+// 已使用次数只保存在内存中，程序重启后重新计数
+type realtimeLimiter struct {
+	mu                sync.Mutex
+	interval          time.Duration
+	quota             int
+	used              int
+	latestRequestTime time.Time
+}
+
+// 占用一次额度和下一个可以请求的时间，并等待到该时间，次数已用完时返回错误
+//
+// 等待期间不持有锁，并发的请求会依次占用之后的请求时间
+func (l *realtimeLimiter) wait() error {
+	l.mu.Lock()
+	if l.quota > 0 && l.used >= l.quota {
+		l.mu.Unlock()
+		return ErrRealtimeQuotaExceeded
+	}
+	now := time.Now()
+	next := now
+	if !l.latestRequestTime.IsZero() {
+		if t := l.latestRequestTime.Add(l.interval); t.After(now) {
+			next = t
+		}
+	}
+	l.latestRequestTime = next
+	l.used++
+	l.mu.Unlock()
+
+	if d := next.Sub(now); d > 0 {
+		time.Sleep(d)
+	}
+	return nil
+}
+
+// 请求失败时归还占用的额度
+func (l *realtimeLimiter) release() {
+	l.mu.Lock()
+	l.used--
+	l.mu.Unlock()
+}
+
+type realtimeService struct {
+	service
+	limiter *realtimeLimiter
+}
+
+func newRealtimeService(s service) realtimeService {
+	interval := time.Second
+	if s.config.RealtimeIntervalTime > 0 {
+		interval = time.Duration(s.config.RealtimeIntervalTime) * time.Millisecond
+	}
+	return realtimeService{
+		service: s,
+		limiter: &realtimeLimiter{interval: interval, quota: s.config.RealtimeQuota},
+	}
+}
+
+type RealtimeRequest struct {
+	TrackingNumber          string `json:"tracking_number"`                     // 包裹物流单号
+	CourierCode             string `json:"courier_code"`                        // 物流商对应的唯一简码
+	DestinationCode         string `json:"destination_code,omitempty"`          // 目的国的二字简码
+	TrackingShippingDate    string `json:"tracking_shipping_date,omitempty"`    // 包裹的发货时间，其格式为：YYYYMMDD，有部分的物流商（如 deutsch-post）需要这个参数（例子：20200102）
+	TrackingPostalCode      string `json:"tracking_postal_code,omitempty"`      // 收件人所在地邮编，仅有部分的物流商（如 postnl-3s）需要这个参数
+	TrackingDestinationCode string `json:"tracking_destination_code,omitempty"` // 目的国对应的二字简码，部分物流商（如postnl-3s）需要这个参数
+	TrackingCourierAccount  string `json:"tracking_courier_account,omitempty"`  // 物流商的官方账号，仅有部分的物流商（如 dynamic-logistics）需要这个参数
+	Lang                    string `json:"lang,omitempty"`                      // 查询结果的语言（cn, en），默认为 en
+}
+
+func (m RealtimeRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.TrackingNumber, validation.Required.ErrorObject(messageError("tracking_number_required"))),
+		validation.Field(&m.CourierCode, validation.Required.ErrorObject(messageError("courier_code_required"))),
+		validation.Field(&m.TrackingShippingDate,
+			courierRequiredRule(m.CourierCode, TrackingShippingDateField),
+			validation.When(m.TrackingShippingDate != "", validation.Date("20060102").ErrorObject(messageError("tracking_shipping_date_invalid"))),
+		),
+		validation.Field(&m.TrackingPostalCode, courierRequiredRule(m.CourierCode, TrackingPostalCodeField)),
+		validation.Field(&m.DestinationCode, countryCodeRule("destination_code_invalid")),
+		validation.Field(&m.TrackingDestinationCode,
+			courierRequiredRule(m.CourierCode, TrackingDestinationCodeField),
+			countryCodeRule("destination_code_invalid"),
+		),
+		validation.Field(&m.TrackingCourierAccount, courierRequiredRule(m.CourierCode, TrackingCourierAccountField)),
+		validation.Field(&m.Lang, validation.When(m.Lang != "", validation.In(ChineseLanguage, EnglishLanguage).ErrorObject(messageError("lang_invalid")))),
+	)
+}
+
+// Query 实时查询包裹的物流信息（同一个请求中返回物流节点，不需要先添加单号）
+//
+// 实时查询有单独的请求间隔（RealtimeIntervalTime）和次数额度（RealtimeQuota），只有查询成功的请求才会计入已使用次数。
+// 已使用次数只保存在内存中，程序重启或者重新创建客户端后重新计数。
+func (s realtimeService) Query(req RealtimeRequest) (track Track, err error) {
+	if err = validate(req, s.config.Language); err != nil {
+		return
+	}
+	if err = s.limiter.wait(); err != nil {
+		err = TranslateError(err, s.config.Language)
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			s.limiter.release()
+		}
+	}()
+
+	resp, err := s.httpClient.R().SetBody(req).Post("/realtime")
+	if err != nil {
+		return
+	}
+
+	res := struct {
+		NormalResponse
+		Data Track `json:"data"`
+	}{}
+	if err = json.Unmarshal(resp.Body(), &res); err == nil {
+		track = res.Data
+	}
+	return
+}
+
+// Quota 返回实时查询已使用的次数和剩余次数（当前客户端启动后的统计），没有设置额度时剩余次数为 -1
+func (s realtimeService) Quota() (used, remaining int) {
+	s.limiter.mu.Lock()
+	defer s.limiter.mu.Unlock()
+	used, remaining = s.limiter.used, -1
+	if s.limiter.quota > 0 {
+		remaining = s.limiter.quota - s.limiter.used
+	}
+	return
+}
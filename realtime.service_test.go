@@ -0,0 +1,101 @@
+package tracking51
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hiscaler/51tracking-go/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRealtimeRequest_Validate(t *testing.T) {
+	tests := []struct {
+		req   RealtimeRequest
+		valid bool
+	}{
+		{RealtimeRequest{TrackingNumber: "A", CourierCode: "usps"}, true},
+		{RealtimeRequest{TrackingNumber: "A"}, false},
+		{RealtimeRequest{TrackingNumber: "A", CourierCode: "deutsch-post"}, false},
+		{RealtimeRequest{TrackingNumber: "A", CourierCode: "deutsch-post", TrackingShippingDate: "20220701"}, true},
+		{RealtimeRequest{TrackingNumber: "A", CourierCode: "postnl-3s", TrackingPostalCode: "1000"}, false},
+		{RealtimeRequest{TrackingNumber: "A", CourierCode: "usps", Lang: "de"}, false},
+	}
+	for i, test := range tests {
+		if err := test.req.Validate(); (err == nil) != test.valid {
+			t.Errorf("%d: expected valid %v, got error %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestRealtimeService_Query(t *testing.T) {
+	var times []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if r.Method != http.MethodPost || r.URL.Path != "/realtime" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var req RealtimeRequest
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &req)
+		writeTestResponse(w, Track{TrackingNumber: req.TrackingNumber, CourierCode: req.CourierCode, DeliveryStatus: StatusTransit})
+	}))
+	defer ts.Close()
+	c := NewTracking51(config.Config{AppKey: "test", RealtimeIntervalTime: 100, RealtimeQuota: 2})
+	c.httpClient.SetBaseURL(ts.URL)
+
+	for i := 0; i < 2; i++ {
+		track, err := c.Services.Realtime.Query(RealtimeRequest{TrackingNumber: "A", CourierCode: "usps"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if track.TrackingNumber != "A" || track.DeliveryStatus != StatusTransit {
+			t.Errorf("unexpected track: %#v", track)
+		}
+	}
+	if len(times) != 2 || times[1].Sub(times[0]) < 90*time.Millisecond {
+		t.Errorf("expected requests at least 100ms apart, got %v", times)
+	}
+	if used, remaining := c.Services.Realtime.Quota(); used != 2 || remaining != 0 {
+		t.Errorf("expected 2 used and 0 remaining, got %d, %d", used, remaining)
+	}
+	if _, err := c.Services.Realtime.Query(RealtimeRequest{TrackingNumber: "A", CourierCode: "usps"}); !errors.Is(err, ErrRealtimeQuotaExceeded) {
+		t.Errorf("expected ErrRealtimeQuotaExceeded, got %v", err)
+	}
+}
+
+func TestRealtimeLimiter_Wait(t *testing.T) {
+	l := &realtimeLimiter{interval: 200 * time.Millisecond}
+	if err := l.wait(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		l.wait()
+		close(done)
+	}()
+
+	// 等待期间不持有锁
+	for {
+		l.mu.Lock()
+		used := l.used
+		l.mu.Unlock()
+		if used == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	l.release()
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("release blocked for %v", d)
+	}
+	select {
+	case <-done:
+		t.Error("wait should sleep until the reserved time")
+	default:
+	}
+	<-done
+}
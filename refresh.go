@@ -0,0 +1,216 @@
+package tracking51
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// 手动更新检查和定时手动更新
+//
+// 51Tracking 只允许手动更新以下已经停止更新的单号：
+//   - 查询不到超过 15 天；
+//   - 已经签收；
+//   - 添加时间超过 30 天。
+
+// RefreshReason 可以或者不能手动更新的原因（同时也是消息标识，可以通过 Message 获取对应语言的说明）
+type RefreshReason string
+
+const (
+	RefreshReasonNotFoundStopped  RefreshReason = "refresh_notfound_stopped"   // 查询不到超过 15 天，已经停止更新
+	RefreshReasonDeliveredStopped RefreshReason = "refresh_delivered_stopped"  // 已经签收，并且已经停止更新
+	RefreshReasonAgedStopped      RefreshReason = "refresh_aged_stopped"       // 添加时间超过 30 天，已经停止更新
+	RefreshReasonUpdating         RefreshReason = "refresh_updating"           // 仍在自动更新
+	RefreshReasonNotFoundTooNew   RefreshReason = "refresh_notfound_too_new"   // 查询不到未超过 15 天
+	RefreshReasonTooNew           RefreshReason = "refresh_too_new"            // 添加时间未超过 30 天
+	RefreshReasonCreatedAtUnknown RefreshReason = "refresh_created_at_unknown" // 无法获取添加时间
+)
+
+// RefreshEligibility 包裹是否可以手动更新
+type RefreshEligibility struct {
+	Eligible bool          `json:"eligible"` // 是否可以手动更新
+	Reason   RefreshReason `json:"reason"`   // 原因
+}
+
+// Message 返回指定语言的原因说明
+func (e RefreshEligibility) Message(lang string) string {
+	return Message(lang, string(e.Reason))
+}
+
+// CheckRefreshEligibility 根据 README 中的手动更新条件检查包裹在 now 时是否可以手动更新，避免提交后返回 RefreshResultError
+func CheckRefreshEligibility(track Track, now time.Time) RefreshEligibility {
+	if track.Updating {
+		return RefreshEligibility{Reason: RefreshReasonUpdating}
+	}
+	if track.DeliveryStatus == StatusDelivered {
+		return RefreshEligibility{Eligible: true, Reason: RefreshReasonDeliveredStopped}
+	}
+
+	createdAt, ok := parseTime(track.CreatedAt)
+	if !ok {
+		return RefreshEligibility{Reason: RefreshReasonCreatedAtUnknown}
+	}
+	days := now.Sub(createdAt).Hours() / 24
+	if track.DeliveryStatus == StatusNotFound {
+		if days > 15 {
+			return RefreshEligibility{Eligible: true, Reason: RefreshReasonNotFoundStopped}
+		}
+		return RefreshEligibility{Reason: RefreshReasonNotFoundTooNew}
+	}
+	if days > 30 {
+		return RefreshEligibility{Eligible: true, Reason: RefreshReasonAgedStopped}
+	}
+	return RefreshEligibility{Reason: RefreshReasonTooNew}
+}
+
+// RefreshSchedulerOptions 定时手动更新选项
+type RefreshSchedulerOptions struct {
+	Interval  time.Duration                                                    // 查找可以手动更新的包裹的间隔时间，默认为 6 小时
+	Cooldown  time.Duration                                                    // 同一包裹两次手动更新的最短间隔时间，默认为 7 天
+	Archived  bool                                                             // 是否包括已归档的包裹
+	OnRefresh func(success []RefreshResultSuccess, error []RefreshResultError) // 每批包裹手动更新后的回调
+	Logger    *log.Logger                                                      // 日志记录器（可以使用 Tracking51.Logger()），默认输出到标准输出
+}
+
+// RefreshReport 一次手动更新的结果
+type RefreshReport struct {
+	Checked int                    `json:"checked"` // 检查的包裹数量
+	Skipped int                    `json:"skipped"` // 可以手动更新，但是距离上次提交未超过冷却时间而跳过的包裹数量
+	Success []RefreshResultSuccess `json:"success"` // 手动更新成功的包裹
+	Error   []RefreshResultError   `json:"error"`   // 手动更新失败的包裹
+}
+
+// RefreshScheduler 定时查找已经停止更新并且可以手动更新的包裹，每次最多提交 40 个
+//
+// 已签收或者查询不到的包裹在手动更新后仍然满足手动更新的条件，为避免每次都重复提交，同一包裹在冷却时间（Cooldown）内只提交一次。
+type RefreshScheduler struct {
+	tracking  TrackingService
+	options   RefreshSchedulerOptions
+	mu        sync.Mutex
+	refreshed map[string]time.Time // 包裹 => 最后一次提交手动更新的时间
+	now       func() time.Time
+}
+
+func NewRefreshScheduler(tracking TrackingService, options RefreshSchedulerOptions) *RefreshScheduler {
+	if options.Interval <= 0 {
+		options.Interval = 6 * time.Hour
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = 7 * 24 * time.Hour
+	}
+	if options.Logger == nil {
+		options.Logger = defaultLogger
+	}
+	return &RefreshScheduler{
+		tracking:  tracking,
+		options:   options,
+		refreshed: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// 可能可以手动更新的包裹的查询条件
+func (s *RefreshScheduler) queries(now time.Time) []TracksQueryParams {
+	archivedStatus := "false"
+	if s.options.Archived {
+		archivedStatus = ""
+	}
+	return []TracksQueryParams{
+		{DeliveryStatus: StatusNotFound, ArchivedStatus: archivedStatus, CreatedDateMin: 1, CreatedDateMax: now.AddDate(0, 0, -15).Unix()},
+		{DeliveryStatus: StatusDelivered, ArchivedStatus: archivedStatus},
+		{ArchivedStatus: archivedStatus, CreatedDateMin: 1, CreatedDateMax: now.AddDate(0, 0, -30).Unix()},
+	}
+}
+
+// Find 查找所有可以手动更新的包裹（不包括冷却时间内已经提交过的包裹），返回检查的包裹数量和跳过的包裹数量
+func (s *RefreshScheduler) Find() (requests RefreshTrackRequests, checked, skipped int, err error) {
+	now := s.now()
+	s.mu.Lock()
+	for key, t := range s.refreshed {
+		if now.Sub(t) >= s.options.Cooldown {
+			delete(s.refreshed, key)
+		}
+	}
+	s.mu.Unlock()
+	seen := make(map[string]struct{})
+	for _, params := range s.queries(now) {
+		reader := s.tracking.Reader(params)
+		for {
+			track, e := reader.Read()
+			if e == io.EOF {
+				break
+			} else if e != nil {
+				return requests, checked, skipped, e
+			}
+			key := trackKey(track.TrackingNumber, track.CourierCode)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			checked++
+			if !CheckRefreshEligibility(track, now).Eligible {
+				continue
+			}
+			s.mu.Lock()
+			_, ok := s.refreshed[key]
+			s.mu.Unlock()
+			if ok {
+				skipped++
+				continue
+			}
+			requests = append(requests, RefreshTrackRequest{TrackingNumber: track.TrackingNumber, CourierCode: track.CourierCode})
+		}
+	}
+	return
+}
+
+// RunOnce 查找并手动更新所有可以手动更新的包裹
+func (s *RefreshScheduler) RunOnce() (report RefreshReport, err error) {
+	requests, checked, skipped, err := s.Find()
+	report.Checked, report.Skipped = checked, skipped
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(requests); i += 40 {
+		j := i + 40
+		if j > len(requests) {
+			j = len(requests)
+		}
+		success, errorItems, e := s.tracking.Refresh(requests[i:j])
+		if e != nil {
+			return report, e
+		}
+		// 提交失败的包裹同样记录，避免每次都重复提交
+		now := s.now()
+		s.mu.Lock()
+		for _, req := range requests[i:j] {
+			s.refreshed[trackKey(req.TrackingNumber, req.CourierCode)] = now
+		}
+		s.mu.Unlock()
+		report.Success = append(report.Success, success...)
+		report.Error = append(report.Error, errorItems...)
+		if s.options.OnRefresh != nil {
+			s.options.OnRefresh(success, errorItems)
+		}
+	}
+	return
+}
+
+// Run 定时手动更新包裹，直到 ctx 被取消
+func (s *RefreshScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.options.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.RunOnce(); err != nil {
+			s.options.Logger.Printf("RefreshScheduler run error: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
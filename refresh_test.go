@@ -0,0 +1,106 @@
+package tracking51
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheckRefreshEligibility(t *testing.T) {
+	now := time.Now()
+	created := func(days int) string {
+		return now.AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+	}
+	for i, testCase := range []struct {
+		track    Track
+		eligible bool
+		reason   RefreshReason
+	}{
+		{Track{Updating: true, DeliveryStatus: StatusDelivered, CreatedAt: created(40)}, false, RefreshReasonUpdating},
+		{Track{DeliveryStatus: StatusDelivered, CreatedAt: created(2)}, true, RefreshReasonDeliveredStopped},
+		{Track{DeliveryStatus: StatusNotFound, CreatedAt: created(16)}, true, RefreshReasonNotFoundStopped},
+		{Track{DeliveryStatus: StatusNotFound, CreatedAt: created(10)}, false, RefreshReasonNotFoundTooNew},
+		{Track{DeliveryStatus: StatusTransit, CreatedAt: created(31)}, true, RefreshReasonAgedStopped},
+		{Track{DeliveryStatus: StatusTransit, CreatedAt: created(20)}, false, RefreshReasonTooNew},
+		{Track{DeliveryStatus: StatusTransit}, false, RefreshReasonCreatedAtUnknown},
+	} {
+		e := CheckRefreshEligibility(testCase.track, now)
+		if e.Eligible != testCase.eligible || e.Reason != testCase.reason {
+			t.Errorf("%d: expected %v %s, got %v %s", i, testCase.eligible, testCase.reason, e.Eligible, e.Reason)
+		}
+	}
+
+	e := RefreshEligibility{Reason: RefreshReasonUpdating}
+	if e.Message(EnglishLanguage) != "Still updating automatically, no need to refresh" {
+		t.Errorf("unexpected message: %s", e.Message(EnglishLanguage))
+	}
+}
+
+func TestRefreshScheduler_RunOnce(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -40).Format("2006-01-02 15:04:05")
+	var batches []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get":
+			var tracks []Track
+			if r.URL.Query().Get("delivery_status") == StatusDelivered {
+				// 45 个已签收的包裹，其中一个仍在更新
+				for i := 0; i < 45; i++ {
+					tracks = append(tracks, Track{TrackingNumber: fmt.Sprintf("D%d", i), CourierCode: "usps", DeliveryStatus: StatusDelivered, CreatedAt: old, Updating: i == 0})
+				}
+			} else if r.URL.Query().Get("delivery_status") == "" {
+				tracks = []Track{
+					{TrackingNumber: "D1", CourierCode: "usps", DeliveryStatus: StatusDelivered, CreatedAt: old},
+					{TrackingNumber: "T1", CourierCode: "usps", DeliveryStatus: StatusTransit, CreatedAt: old},
+				}
+			}
+			writeTestResponse(w, tracks)
+		case "/manualupdate":
+			var req RefreshTrackRequests
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &req)
+			batches = append(batches, len(req))
+			success := make([]trackingNumberCourierCode, len(req))
+			for i := range req {
+				success[i] = trackingNumberCourierCode(req[i])
+			}
+			writeTestResponse(w, map[string]interface{}{"success": success})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	refreshed := 0
+	scheduler := NewRefreshScheduler(c.Services.Tracking, RefreshSchedulerOptions{
+		OnRefresh: func(success []RefreshResultSuccess, error []RefreshResultError) { refreshed += len(success) },
+	})
+	report, err := scheduler.RunOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 46 || len(report.Success) != 45 || refreshed != 45 {
+		t.Errorf("unexpected report: checked %d, success %d, refreshed %d", report.Checked, len(report.Success), refreshed)
+	}
+	if len(batches) != 2 || batches[0] != 40 || batches[1] != 5 {
+		t.Errorf("expected batches of 40 and 5, got %v", batches)
+	}
+
+	// 冷却时间内不会重复提交
+	if report, err = scheduler.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Success) != 0 || report.Skipped != 45 || len(batches) != 2 {
+		t.Errorf("expected all parcels to be skipped, got success %d, skipped %d, batches %v", len(report.Success), report.Skipped, batches)
+	}
+	now := time.Now().Add(7*24*time.Hour + time.Minute)
+	scheduler.now = func() time.Time { return now }
+	if report, err = scheduler.RunOnce(); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Success) != 45 || report.Skipped != 0 {
+		t.Errorf("expected parcels to be refreshed after cooldown, got success %d, skipped %d", len(report.Success), report.Skipped)
+	}
+}
@@ -0,0 +1,206 @@
+package tracking51
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// 包裹物流轨迹页面渲染
+
+// RenderOptions 渲染选项
+type RenderOptions struct {
+	Lang       string    // 页面语言（cn, en），默认为 cn
+	Couriers   []Courier // 物流商列表（用于显示物流商名称、Logo 和官网链接），可以通过 Courier.List 获取
+	Title      string    // 页面标题，为空时使用默认标题
+	TimeLayout string    // 物流节点时间格式，默认为 2006-01-02 15:04
+}
+
+// 渲染页面的文字
+var renderTexts = map[string]map[string]string{
+	ChineseLanguage: {
+		"title":          "物流跟踪",
+		"trackingNumber": "物流单号",
+		"courier":        "物流商",
+		"status":         "状态",
+		"origin":         "发件国",
+		"destination":    "目的国",
+		"empty":          "暂无物流信息",
+	},
+	EnglishLanguage: {
+		"title":          "Tracking",
+		"trackingNumber": "Tracking Number",
+		"courier":        "Courier",
+		"status":         "Status",
+		"origin":         "Origin",
+		"destination":    "Destination",
+		"empty":          "No tracking information available",
+	},
+}
+
+const renderTemplates = `
+{{- define "fragment" -}}
+<div class="tracking51">
+  <div class="tracking51-header">
+    {{- with .Courier}}
+    <div class="tracking51-courier">
+      {{- if .Logo}}<img class="tracking51-courier-logo" src="{{.Logo}}" alt="{{.Name}}">{{end -}}
+      {{- if .URL}}<a href="{{.URL}}" target="_blank" rel="noopener noreferrer">{{.Name}}</a>{{else}}<span>{{.Name}}</span>{{end -}}
+    </div>
+    {{- end}}
+    <div class="tracking51-number"><span class="tracking51-label">{{text "trackingNumber"}}</span> {{.Track.TrackingNumber}}</div>
+    <div class="tracking51-status"><span class="tracking51-label">{{text "status"}}</span> <span class="tracking51-badge tracking51-badge-{{.StatusCode}}">{{.StatusName}}</span></div>
+  </div>
+  {{- if .Checkpoints}}
+  <ol class="tracking51-timeline">
+    {{- range .Checkpoints}}
+    <li class="tracking51-checkpoint tracking51-checkpoint-{{.Source}}">
+      <time class="tracking51-checkpoint-date">{{.Date}}</time>
+      <div class="tracking51-checkpoint-detail">{{.Detail}}</div>
+      {{- if .Location}}<div class="tracking51-checkpoint-location">{{.Location}}</div>{{end}}
+      <div class="tracking51-checkpoint-source">{{text .Source}}</div>
+    </li>
+    {{- end}}
+  </ol>
+  {{- else}}
+  <p class="tracking51-empty">{{text "empty"}}</p>
+  {{- end}}
+</div>
+{{- end -}}
+
+{{- define "page" -}}
+<!DOCTYPE html>
+<html lang="{{.HTMLLang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+.tracking51{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,"PingFang SC","Microsoft YaHei",sans-serif;max-width:720px;margin:0 auto;color:#333}
+.tracking51-header{padding:16px 0;border-bottom:1px solid #eee}
+.tracking51-header>div{margin:4px 0}
+.tracking51-label{color:#999;margin-right:8px}
+.tracking51-courier-logo{height:24px;vertical-align:middle;margin-right:8px}
+.tracking51-badge{display:inline-block;padding:2px 8px;border-radius:10px;color:#fff;background:#999;font-size:12px}
+.tracking51-badge-transit,.tracking51-badge-inforeceived{background:#1890ff}
+.tracking51-badge-pickup{background:#13c2c2}
+.tracking51-badge-delivered{background:#52c41a}
+.tracking51-badge-undelivered,.tracking51-badge-expired{background:#fa8c16}
+.tracking51-badge-exception{background:#f5222d}
+.tracking51-timeline{list-style:none;margin:0;padding:16px 0 0 16px;border-left:2px solid #eee}
+.tracking51-checkpoint{position:relative;padding:0 0 16px 16px}
+.tracking51-checkpoint:before{content:"";position:absolute;left:-23px;top:4px;width:10px;height:10px;border-radius:50%;background:#ccc}
+.tracking51-checkpoint:first-child:before{background:#1890ff}
+.tracking51-checkpoint-date,.tracking51-checkpoint-location,.tracking51-checkpoint-source{color:#999;font-size:12px}
+.tracking51-empty{color:#999;text-align:center;padding:32px 0}
+</style>
+</head>
+<body>
+{{template "fragment" .}}
+</body>
+</html>
+{{- end -}}
+`
+
+type renderCheckpoint struct {
+	Source   string // origin, destination
+	Date     string
+	Detail   string
+	Location string
+}
+
+type renderCourier struct {
+	Name string
+	Logo string
+	URL  string
+}
+
+type renderData struct {
+	Title       string
+	HTMLLang    string
+	Track       Track
+	Courier     *renderCourier
+	StatusCode  string
+	StatusName  string
+	Checkpoints []renderCheckpoint
+}
+
+// Renderer 将包裹数据渲染为物流轨迹 HTML，物流商返回的文字都会被转义
+type Renderer struct {
+	options  RenderOptions
+	couriers map[string]Courier
+	template *template.Template
+}
+
+func NewRenderer(options RenderOptions) *Renderer {
+	options.Lang = strings.ToLower(options.Lang)
+	if options.Lang != EnglishLanguage {
+		options.Lang = ChineseLanguage
+	}
+	if options.TimeLayout == "" {
+		options.TimeLayout = "2006-01-02 15:04"
+	}
+	if options.Title == "" {
+		options.Title = renderTexts[options.Lang]["title"]
+	}
+	r := &Renderer{
+		options:  options,
+		couriers: make(map[string]Courier, len(options.Couriers)),
+	}
+	for _, courier := range options.Couriers {
+		r.couriers[courier.Code] = courier
+	}
+	r.template = template.Must(template.New("tracking51").Funcs(template.FuncMap{
+		"text": func(key string) string {
+			return renderTexts[options.Lang][key]
+		},
+	}).Parse(renderTemplates))
+	return r
+}
+
+// 合并发件国和目的国的物流节点（去除重复节点），按时间倒序排列
+func (r *Renderer) checkpoints(track Track) []renderCheckpoint {
+	events := MergeTimeline(track, TimelineOptions{Descending: true})
+	checkpoints := make([]renderCheckpoint, len(events))
+	for i, event := range events {
+		date := event.CheckpointDate
+		if !event.Time.IsZero() {
+			date = event.Time.Format(r.options.TimeLayout)
+		}
+		checkpoints[i] = renderCheckpoint{Source: event.Source, Date: date, Detail: event.TrackingDetail, Location: event.Location}
+	}
+	return checkpoints
+}
+
+func (r *Renderer) data(track Track) renderData {
+	d := renderData{
+		Title:       r.options.Title,
+		HTMLLang:    "zh-CN",
+		Track:       track,
+		StatusCode:  strings.ToLower(track.DeliveryStatus),
+		StatusName:  DeliveryStatusName(track.DeliveryStatus, r.options.Lang),
+		Checkpoints: r.checkpoints(track),
+	}
+	if r.options.Lang == EnglishLanguage {
+		d.HTMLLang = "en"
+	}
+	if courier, ok := r.couriers[track.CourierCode]; ok {
+		d.Courier = &renderCourier{Name: courier.Name, Logo: courier.Logo, URL: courier.URL.String}
+	} else if track.CourierCode != "" {
+		d.Courier = &renderCourier{Name: track.CourierCode}
+	}
+	if d.Courier != nil && d.Courier.URL == "" {
+		d.Courier.URL = track.OriginInfo.Weblink
+	}
+	return d
+}
+
+// Fragment 渲染物流轨迹 HTML 片段，用于嵌入到已有的页面中
+func (r *Renderer) Fragment(w io.Writer, track Track) error {
+	return r.template.ExecuteTemplate(w, "fragment", r.data(track))
+}
+
+// Page 渲染完整的物流轨迹页面（包含默认样式）
+func (r *Renderer) Page(w io.Writer, track Track) error {
+	return r.template.ExecuteTemplate(w, "page", r.data(track))
+}
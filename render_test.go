@@ -0,0 +1,49 @@
+package tracking51
+
+import (
+	"bytes"
+	"gopkg.in/guregu/null.v4"
+	"strings"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	track := Track{
+		TrackingNumber: "LX123456789CN",
+		CourierCode:    "china-post",
+		DeliveryStatus: StatusDelivered,
+		OriginInfo: Information{TrackInfo: []TrackInformation{
+			{CheckpointDate: "2022-07-01 10:00:00", TrackingDetail: "<script>alert(1)</script>", Location: "Shenzhen"},
+		}},
+		DestinationInfo: Information{TrackInfo: []TrackInformation{
+			{CheckpointDate: "2022-07-08 10:00:00", TrackingDetail: "Delivered", Location: "New York"},
+		}},
+	}
+	r := NewRenderer(RenderOptions{
+		Lang:     EnglishLanguage,
+		Couriers: []Courier{{Code: "china-post", Name: "China Post", URL: null.StringFrom("javascript:alert(1)")}},
+	})
+
+	var buf bytes.Buffer
+	if err := r.Fragment(&buf, track); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if strings.Contains(s, "<script>") || strings.Contains(s, "javascript:") {
+		t.Errorf("carrier text should be escaped: %s", s)
+	}
+	if !strings.Contains(s, "China Post") || !strings.Contains(s, "tracking51-badge-delivered") || !strings.Contains(s, ">Delivered<") {
+		t.Errorf("unexpected fragment: %s", s)
+	}
+	if strings.Index(s, "New York") > strings.Index(s, "Shenzhen") {
+		t.Error("checkpoints should be sorted by date descending")
+	}
+
+	buf.Reset()
+	if err := r.Page(&buf, Track{}); err != nil {
+		t.Fatal(err)
+	}
+	if s = buf.String(); !strings.HasPrefix(s, "<!DOCTYPE html>") || !strings.Contains(s, "No tracking information available") {
+		t.Errorf("unexpected page: %s", s)
+	}
+}
@@ -0,0 +1,126 @@
+package tracking51
+
+import (
+	"encoding/json"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"os"
+	"sync"
+)
+
+// 物流商需要的额外字段
+
+const (
+	TrackingShippingDateField    = "tracking_shipping_date"    // 包裹的发货时间（YYYYMMDD）
+	TrackingPostalCodeField      = "tracking_postal_code"      // 收件人所在地邮编
+	TrackingDestinationCodeField = "tracking_destination_code" // 目的国二字简码
+	TrackingCourierAccountField  = "tracking_courier_account"  // 物流商官方账号
+)
+
+// 字段 => 字段名称的消息标识
+var requirementFieldMessageIDs = map[string]string{
+	TrackingShippingDateField:    "requirement_tracking_shipping_date",
+	TrackingPostalCodeField:      "requirement_tracking_postal_code",
+	TrackingDestinationCodeField: "requirement_tracking_destination_code",
+	TrackingCourierAccountField:  "requirement_tracking_courier_account",
+}
+
+// RequirementFieldName 返回物流商额外字段的名称（cn, en），未知的字段返回原值
+func RequirementFieldName(field, lang string) string {
+	id, ok := requirementFieldMessageIDs[field]
+	if !ok {
+		return field
+	}
+	return Message(lang, id)
+}
+
+// 内置的物流商额外字段
+var builtinCourierRequirements = map[string][]string{
+	"deutsch-post":      {TrackingShippingDateField},
+	"postnl-3s":         {TrackingPostalCodeField, TrackingDestinationCodeField},
+	"dynamic-logistics": {TrackingCourierAccountField},
+}
+
+// CourierRequirements 物流商需要的额外字段登记表，添加、修改单号和实时查询时会检查这些字段是否填写
+type CourierRequirements struct {
+	mu    sync.RWMutex
+	items map[string][]string
+}
+
+// NewCourierRequirements 创建包含内置数据的登记表
+func NewCourierRequirements() *CourierRequirements {
+	r := &CourierRequirements{items: make(map[string][]string, len(builtinCourierRequirements))}
+	for courierCode, fields := range builtinCourierRequirements {
+		r.items[courierCode] = fields
+	}
+	return r
+}
+
+// DefaultCourierRequirements 默认的登记表
+var DefaultCourierRequirements = NewCourierRequirements()
+
+// Set 设置物流商需要的额外字段，不传入字段时表示不需要额外字段
+func (r *CourierRequirements) Set(courierCode string, fields ...string) error {
+	for _, field := range fields {
+		if _, ok := requirementFieldMessageIDs[field]; !ok {
+			return messageErrorf("requirement_field_invalid", map[string]interface{}{"field": field})
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(fields) == 0 {
+		delete(r.items, courierCode)
+	} else {
+		r.items[courierCode] = append([]string(nil), fields...)
+	}
+	return nil
+}
+
+// Fields 返回物流商需要的额外字段
+func (r *CourierRequirements) Fields(courierCode string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.items[courierCode]...)
+}
+
+// Requires 物流商是否需要指定的字段
+func (r *CourierRequirements) Requires(courierCode, field string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.items[courierCode] {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFile 从 JSON 文件（格式为：{"物流商简码": ["字段", ...]}）中读取数据，覆盖已有物流商的数据
+func (r *CourierRequirements) LoadFile(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	items := make(map[string][]string)
+	if err = json.Unmarshal(b, &items); err != nil {
+		return err
+	}
+	for courierCode, fields := range items {
+		for _, field := range fields {
+			if _, ok := requirementFieldMessageIDs[field]; !ok {
+				return messageErrorf("courier_requirement_field_invalid", map[string]interface{}{"courier": courierCode, "field": field})
+			}
+		}
+	}
+	for courierCode, fields := range items {
+		r.Set(courierCode, fields...)
+	}
+	return nil
+}
+
+// 物流商需要该字段时不能为空
+func courierRequiredRule(courierCode, field string) validation.Rule {
+	return validation.When(
+		DefaultCourierRequirements.Requires(courierCode, field),
+		validation.Required.ErrorObject(messageErrorf("courier_requires_"+field, map[string]interface{}{"courier": courierCode})),
+	)
+}
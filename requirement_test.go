@@ -0,0 +1,60 @@
+package tracking51
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCourierRequirements_LoadFile(t *testing.T) {
+	r := NewCourierRequirements()
+	filename := filepath.Join(t.TempDir(), "requirements.json")
+	os.WriteFile(filename, []byte(`{"postnl-3s": ["tracking_postal_code"], "fedex": ["tracking_shipping_date"]}`), 0644)
+	if err := r.LoadFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if fields := r.Fields("postnl-3s"); len(fields) != 1 || fields[0] != TrackingPostalCodeField {
+		t.Errorf("unexpected postnl-3s fields: %v", fields)
+	}
+	if !r.Requires("fedex", TrackingShippingDateField) || !r.Requires("deutsch-post", TrackingShippingDateField) {
+		t.Error("expected fedex and deutsch-post to require tracking_shipping_date")
+	}
+
+	os.WriteFile(filename, []byte(`{"usps": ["unknown"]}`), 0644)
+	if err := r.LoadFile(filename); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestCreateTrackRequests_Validate(t *testing.T) {
+	req := CreateTrackRequests{
+		{TrackingNumber: "A", CourierCode: "usps"},
+		{TrackingNumber: "B", CourierCode: "postnl-3s", TrackingPostalCode: "1000AA"},
+	}
+	err := req.Validate()
+	if err == nil || !strings.Contains(err.Error(), "第 2 个单号 B") || !strings.Contains(err.Error(), "目的国二字简码") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	req[1].TrackingDestinationCode = "NL"
+	if err = req.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err = (UpdateTrackRequest{TrackingNumber: "C", CourierCode: "dynamic-logistics"}).Validate(); err == nil {
+		t.Error("expected error for missing tracking_courier_account")
+	}
+}
+
+func TestRequirementFieldName(t *testing.T) {
+	if name := RequirementFieldName(TrackingPostalCodeField, EnglishLanguage); name != "Recipient postal code" {
+		t.Errorf("unexpected name: %s", name)
+	}
+	if name := RequirementFieldName(TrackingPostalCodeField, ChineseLanguage); name != "收件人所在地邮编" {
+		t.Errorf("unexpected name: %s", name)
+	}
+	if name := RequirementFieldName("unknown", EnglishLanguage); name != "unknown" {
+		t.Errorf("unexpected name: %s", name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/hiscaler/51tracking-go/config"
 	"log"
+	"os"
 )
 
 type service struct {
@@ -12,9 +13,54 @@ type service struct {
 	httpClient *resty.Client  // HTTP client
 }
 
+// AccountService 帐号服务
+type AccountService interface {
+	Profile() (profile AccountProfile, err error)
+}
+
+// CourierService 物流商服务
+type CourierService interface {
+	List(lang string) (items []Courier, err error)
+	Update(trackingNumber, oldCourierCode, newCourierCode string) error
+}
+
+// TrackingService 物流单号服务
+type TrackingService interface {
+	Create(req CreateTrackRequest) (success []CreateResult, error []CreateResult, err error)
+	BatchCreate(req CreateTrackRequests) (success []CreateResult, error []CreateResult, err error)
+	Update(req UpdateTrackRequest) (success []UpdateResult, error []UpdateResult, err error)
+	Query(params TracksQueryParams) (items []Track, isLastPage bool, err error)
+	Reader(params TracksQueryParams) TrackReader
+	Delete(req DeleteTrackRequests) (success []DeleteTrackResult, error []DeleteTrackResult, err error)
+	StopUpdate(req StopUpdateTrackRequests) (success []StopUpdateResultSuccess, error []StopUpdateResultError, err error)
+	Archive(req ArchiveTrackRequests) (success []ArchiveResultSuccess, error []ArchiveResultError, err error)
+	Unarchive(req ArchiveTrackRequests) (success []ArchiveResultSuccess, error []ArchiveResultError, err error)
+	Refresh(req RefreshTrackRequests) (success []RefreshResultSuccess, error []RefreshResultError, err error)
+	StatusStatistic(req StatusStatisticRequest) (stat StatusStatistic, err error)
+	TransitTime(req TransitTimeRequests) (success []TransitTime, error []TransitTime, err error)
+	RemoteDetection(req RemoteDetectionRequest) (item RemoteDetectionResult, err error)
+}
+
+// RealtimeService 实时查询服务
+type RealtimeService interface {
+	Query(req RealtimeRequest) (track Track, err error)
+	Quota() (used, remaining int)
+}
+
+var (
+	_ AccountService  = accountService{}
+	_ CourierService  = courierService{}
+	_ TrackingService = trackingService{}
+	_ RealtimeService = realtimeService{}
+)
+
 // API Services
 type services struct {
-	Account  accountService
-	Courier  courierService
-	Tracking trackingService
+	Account  AccountService
+	Courier  CourierService
+	Tracking TrackingService
+	Realtime RealtimeService
 }
+
+// 没有设置日志记录器的选项（比如 SyncOptions.Logger）使用的日志记录器
+var defaultLogger = log.New(os.Stdout, "[ 51Tracking ] ", log.LstdFlags|log.Llongfile)
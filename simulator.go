@@ -0,0 +1,108 @@
+package tracking51
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"time"
+)
+
+// 推送模拟，用于在本地测试 Webhook 接收服务
+
+// NewWebhookRequest 使用包裹数据生成推送数据，并使用 email 计算 verify.signature（与 Webhook.Valid 的验证方式一致）
+func NewWebhookRequest(track Track, email string, timestamp int) WebhookRequest {
+	return WebhookRequest{
+		Code:    Success,
+		Message: "Success",
+		Data: Webhook{
+			Track: track,
+			Verify: webhookVerify{
+				Timestamp: timestamp,
+				Signature: webhookSignature(email, timestamp),
+			},
+		},
+	}
+}
+
+// SendWebhook 将推送数据 POST 到指定的地址
+func SendWebhook(url string, wr WebhookRequest) error {
+	resp, err := resty.New().
+		SetTimeout(10 * time.Second).
+		R().
+		SetHeaders(map[string]string{
+			"Content-Type": "application/json",
+			"User-Agent":   userAgent,
+		}).
+		SetBody(wr).
+		Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("%s: %s", resp.Status(), resp.String())
+	}
+	return nil
+}
+
+// WebhookTimelineStep 模拟的包裹状态变化
+type WebhookTimelineStep struct {
+	Delay          time.Duration    `json:"delay"`           // 发送前等待的时间
+	DeliveryStatus string           `json:"delivery_status"` // 包裹状态
+	SubStatus      string           `json:"substatus"`       // 包裹子状态
+	Destination    bool             `json:"destination"`     // 物流节点是否添加到目的国的物流信息中，默认添加到发件国的物流信息中
+	Checkpoint     TrackInformation `json:"checkpoint"`      // 新增的物流节点（为空时不添加）
+}
+
+// WebhookTimeline 根据状态变化依次生成包裹数据，物流节点按 51Tracking 的返回顺序（最新的在前）添加
+func WebhookTimeline(track Track, steps []WebhookTimelineStep) []Track {
+	tracks := make([]Track, len(steps))
+	for i, step := range steps {
+		if step.DeliveryStatus != "" {
+			track.DeliveryStatus = step.DeliveryStatus
+			track.SubStatus = step.SubStatus
+		}
+		if c := step.Checkpoint; c != (TrackInformation{}) {
+			if c.CheckpointDeliveryStatus == "" {
+				c.CheckpointDeliveryStatus = track.DeliveryStatus
+			}
+			info := &track.OriginInfo
+			if step.Destination {
+				info = &track.DestinationInfo
+			}
+			info.TrackInfo = append([]TrackInformation{c}, info.TrackInfo...)
+			track.StatusInfo = c.TrackingDetail
+			track.LatestEvent = fmt.Sprintf("%s,%s,%s", c.TrackingDetail, c.Location, c.CheckpointDate)
+			track.LatestCheckpointTime = c.CheckpointDate
+		}
+		// 复制物流节点，避免后续的修改影响已生成的数据
+		track.OriginInfo.TrackInfo = append([]TrackInformation(nil), track.OriginInfo.TrackInfo...)
+		track.DestinationInfo.TrackInfo = append([]TrackInformation(nil), track.DestinationInfo.TrackInfo...)
+		tracks[i] = track
+	}
+	return tracks
+}
+
+// ReplayWebhooks 按状态变化依次发送推送，每次发送前等待 step.Delay，推送时间为实际发送的时间
+//
+// 推送按（单号、推送时间、签名）去重，同一秒内发送的多个推送的推送时间会依次加 1 秒，避免被当作重复的推送。
+func ReplayWebhooks(ctx context.Context, url, email string, track Track, steps []WebhookTimelineStep) error {
+	last := 0
+	for i, t := range WebhookTimeline(track, steps) {
+		if d := steps[i].Delay; d > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+			}
+		}
+		timestamp := int(time.Now().Unix())
+		if timestamp <= last {
+			timestamp = last + 1
+		}
+		last = timestamp
+		if err := SendWebhook(url, NewWebhookRequest(t, email, timestamp)); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
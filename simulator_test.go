@@ -0,0 +1,42 @@
+package tracking51
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReplayWebhooks(t *testing.T) {
+	email := "test@example.com"
+	var statuses []string
+	ts := httptest.NewServer(NewWebhookHandler(WebhookHandlerOptions{
+		Email: email,
+		OnWebhook: func(wh Webhook) error {
+			statuses = append(statuses, wh.DeliveryStatus)
+			return nil
+		},
+	}))
+	defer ts.Close()
+
+	steps := []WebhookTimelineStep{
+		{DeliveryStatus: StatusTransit, Checkpoint: TrackInformation{CheckpointDate: "2022-07-01 10:00:00", TrackingDetail: "Posting"}},
+		{Delay: time.Millisecond, DeliveryStatus: StatusDelivered, Destination: true, Checkpoint: TrackInformation{CheckpointDate: "2022-07-08 10:00:00", TrackingDetail: "Delivered"}},
+	}
+	err := ReplayWebhooks(context.Background(), ts.URL, email, Track{TrackingNumber: "LX123456789CN", CourierCode: "china-post"}, steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[1] != StatusDelivered {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+
+	tracks := WebhookTimeline(Track{}, steps)
+	if len(tracks[0].DestinationInfo.TrackInfo) != 0 || len(tracks[1].DestinationInfo.TrackInfo) != 1 {
+		t.Error("timeline tracks should not share checkpoints")
+	}
+
+	if err = SendWebhook(ts.URL, NewWebhookRequest(Track{}, "other@example.com", int(time.Now().Unix()))); err == nil {
+		t.Error("expected invalid signature error")
+	}
+}
@@ -0,0 +1,414 @@
+package tracking51
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 推送转发
+
+// WebhookSink 推送转发目标
+type WebhookSink interface {
+	Send(wh Webhook) error
+}
+
+// WebhookSinkFunc 使用函数作为推送转发目标
+type WebhookSinkFunc func(wh Webhook) error
+
+func (fn WebhookSinkFunc) Send(wh Webhook) error {
+	return fn(wh)
+}
+
+// HTTPSink 将推送数据以 JSON 格式 POST 到指定的地址
+//
+// 请求头 X-Webhook-Timestamp 为发送时间（时间戳格式），X-Webhook-Signature 为使用 secret 对“时间戳.请求内容”进行 HMAC-SHA256 签名后的十六进制字符串。
+type HTTPSink struct {
+	url        string
+	secret     string
+	httpClient *resty.Client
+}
+
+// NewHTTPSink 创建 HTTP 转发目标，请求失败或者返回 5xx、429 状态码时会重试 retryCount 次
+func NewHTTPSink(url, secret string, retryCount int) *HTTPSink {
+	httpClient := resty.New().
+		SetTimeout(10*time.Second).
+		SetHeader("User-Agent", userAgent).
+		SetRetryCount(retryCount).
+		SetRetryWaitTime(1 * time.Second).
+		SetRetryMaxWaitTime(10 * time.Second).
+		AddRetryCondition(func(response *resty.Response, err error) bool {
+			return err != nil || response == nil || response.StatusCode() >= 500 || response.StatusCode() == 429
+		})
+	return &HTTPSink{url: url, secret: secret, httpClient: httpClient}
+}
+
+// HTTPSinkSignature 计算 HTTPSink 请求的签名，接收方可以使用该函数验证请求
+func HTTPSinkSignature(secret string, timestamp int64, body []byte) string {
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (s *HTTPSink) Send(wh Webhook) error {
+	body, err := json.Marshal(wh)
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	resp, err := s.httpClient.R().
+		SetHeaders(map[string]string{
+			"Content-Type":        "application/json",
+			"X-Webhook-Timestamp": strconv.FormatInt(timestamp, 10),
+			"X-Webhook-Signature": HTTPSinkSignature(s.secret, timestamp, body),
+		}).
+		SetBody(body).
+		Post(s.url)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("%s: %s", resp.Status(), resp.String())
+	}
+	return nil
+}
+
+// FileSink 将推送数据以 JSON Lines 格式追加到文件中
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Send(wh Webhook) error {
+	b, err := json.Marshal(wh)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ChannelSink 将推送数据发送到通道中（通道已满时会阻塞）
+type ChannelSink chan<- Webhook
+
+func (s ChannelSink) Send(wh Webhook) error {
+	s <- wh
+	return nil
+}
+
+// 重试队列
+
+type retryEntry struct {
+	Sink     string  `json:"sink"`     // 转发目标名称
+	Attempts int     `json:"attempts"` // 已重试次数
+	Error    string  `json:"error"`    // 最后一次失败的错误信息
+	Webhook  Webhook `json:"webhook"`  // 推送数据
+}
+
+// RetryQueue 持久化的重试队列，每条记录保存为目录中的一个文件
+//
+// 重试次数达到上限（默认为 10 次）的记录会移到目录下的 dead 子目录（死信）中，不再重试，可以通过 Requeue 重新加入队列。
+type RetryQueue struct {
+	mu          sync.Mutex // 保护队列文件
+	retrying    sync.Mutex // 保证同一时间只有一个 Retry 在执行，发送期间不会阻塞 Push
+	dir         string
+	seq         uint64
+	maxAttempts int
+}
+
+// NewRetryQueue 打开重试队列，目录不存在时会自动创建
+func NewRetryQueue(dir string) (*RetryQueue, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "dead"), 0755); err != nil {
+		return nil, err
+	}
+	return &RetryQueue{dir: dir, maxAttempts: 10}, nil
+}
+
+// SetMaxAttempts 设置最大重试次数，小于等于 0 表示不限制
+func (q *RetryQueue) SetMaxAttempts(n int) *RetryQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxAttempts = n
+	return q
+}
+
+func (q *RetryQueue) write(name string, entry retryEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(q.dir, name), b)
+}
+
+// Push 添加到队列
+func (q *RetryQueue) Push(sink string, wh Webhook, cause error) error {
+	_, err := q.push(sink, wh, cause)
+	return err
+}
+
+func (q *RetryQueue) push(sink string, wh Webhook, cause error) (name string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry := retryEntry{Sink: sink, Webhook: wh}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+	// 同一时间添加的记录通过序号区分，文件名按添加顺序排序
+	q.seq++
+	name = fmt.Sprintf("%020d-%010d.json", time.Now().UnixNano(), q.seq)
+	return name, q.write(name, entry)
+}
+
+// Len 队列中的记录数量
+func (q *RetryQueue) Len() (int, error) {
+	names, err := q.names(q.dir)
+	return len(names), err
+}
+
+// DeadLen 死信的数量
+func (q *RetryQueue) DeadLen() (int, error) {
+	names, err := q.names(filepath.Join(q.dir, "dead"))
+	return len(names), err
+}
+
+// Requeue 将所有死信重新加入队列（重试次数清零），无法读取的死信会被跳过
+func (q *RetryQueue) Requeue() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dir := filepath.Join(q.dir, "dead")
+	names, err := q.names(dir)
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, name := range names {
+		entry, err := q.read(filepath.Join(dir, name))
+		if err != nil {
+			// 无法读取的死信保留在 dead 子目录中
+			errs = append(errs, err.Error())
+			continue
+		}
+		entry.Attempts = 0
+		if err = q.write(name, entry); err != nil {
+			return err
+		}
+		if err = os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (q *RetryQueue) names(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (q *RetryQueue) read(path string) (entry retryEntry, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(b, &entry); err != nil {
+		err = fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return
+}
+
+// Retry 按添加顺序重新发送队列中的记录，发送成功的记录会从队列中删除，重试次数达到上限的记录会移到死信中
+//
+// 发送期间不持有队列的锁，Push 不会被缓慢的发送阻塞。无法读取或者内容损坏的记录会移到死信中，
+// 之后继续发送其他记录，最后返回这些记录的错误。
+func (q *RetryQueue) Retry(sinks map[string]WebhookSink) error {
+	q.retrying.Lock()
+	defer q.retrying.Unlock()
+	q.mu.Lock()
+	names, err := q.names(q.dir)
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return q.send(names, sinks, nil)
+}
+
+// 发送队列中的指定记录（需要持有 retrying 锁），onError 在发送失败时调用
+func (q *RetryQueue) send(names []string, sinks map[string]WebhookSink, onError func(sink string, err error)) error {
+	var errs []string
+	for _, name := range names {
+		path := filepath.Join(q.dir, name)
+		entry, err := q.read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			errs = append(errs, err.Error())
+			q.mu.Lock()
+			err = os.Rename(path, filepath.Join(q.dir, "dead", name))
+			q.mu.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		sink, ok := sinks[entry.Sink]
+		if !ok {
+			continue
+		}
+
+		sendErr := sink.Send(entry.Webhook)
+		if sendErr != nil && onError != nil {
+			onError(entry.Sink, sendErr)
+		}
+		q.mu.Lock()
+		if sendErr == nil {
+			err = os.Remove(path)
+		} else {
+			entry.Attempts++
+			entry.Error = sendErr.Error()
+			if err = q.write(name, entry); err == nil && q.maxAttempts > 0 && entry.Attempts >= q.maxAttempts {
+				err = os.Rename(path, filepath.Join(q.dir, "dead", name))
+			}
+		}
+		q.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// FanOut 将推送转发到多个目标
+//
+// 推送先写入持久化的重试队列，之后在后台转发，处理推送时不会等待下游服务。转发失败的推送保留在队列中，
+// 下游故障或者进程重启时不会丢失已经确认接收的推送。
+type FanOut struct {
+	names  []string
+	sinks  map[string]WebhookSink
+	queue  *RetryQueue
+	logger *log.Logger
+	wg     sync.WaitGroup
+}
+
+func NewFanOut(queue *RetryQueue) *FanOut {
+	return &FanOut{
+		sinks:  make(map[string]WebhookSink),
+		queue:  queue,
+		logger: defaultLogger,
+	}
+}
+
+// SetLogger 设置日志记录器
+func (f *FanOut) SetLogger(logger *log.Logger) *FanOut {
+	f.logger = logger
+	return f
+}
+
+// Add 添加转发目标，name 用于在重试队列中标识转发目标，不能重复
+func (f *FanOut) Add(name string, sink WebhookSink) *FanOut {
+	if _, ok := f.sinks[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.sinks[name] = sink
+	return f
+}
+
+// Send 将推送写入重试队列（每个目标一条记录）后在后台转发，只有在写入重试队列失败时才会返回错误
+func (f *FanOut) Send(wh Webhook) error {
+	var names, errs []string
+	for _, sink := range f.names {
+		name, err := f.queue.push(sink, wh, nil)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", sink, err.Error()))
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) != 0 {
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.queue.retrying.Lock()
+			defer f.queue.retrying.Unlock()
+			err := f.queue.send(names, f.sinks, func(sink string, err error) {
+				f.logger.Printf("Send webhook to %s error: %s", sink, err.Error())
+			})
+			if err != nil {
+				f.logger.Printf("Send webhook error: %s", err.Error())
+			}
+		}()
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Wait 等待后台转发完成
+func (f *FanOut) Wait() {
+	f.wg.Wait()
+}
+
+// Retry 重试队列中转发失败的推送
+func (f *FanOut) Retry() error {
+	return f.queue.Retry(f.sinks)
+}
+
+// Run 按指定的时间间隔重试，直到 ctx 被取消
+func (f *FanOut) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := f.Retry(); err != nil {
+				f.logger.Printf("Retry webhook error: %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package tracking51
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHTTPSink_Send(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		if r.Header.Get("X-Webhook-Signature") != HTTPSinkSignature("secret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	if err := NewHTTPSink(ts.URL, "secret", 0).Send(Webhook{}); err != nil {
+		t.Error(err)
+	}
+	if err := NewHTTPSink(ts.URL, "other", 0).Send(Webhook{}); err == nil {
+		t.Error("expected signature error")
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	dir := t.TempDir()
+	queue, err := NewRetryQueue(filepath.Join(dir, "queue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan Webhook, 1)
+	down := true
+	fanOut := NewFanOut(queue).
+		Add("file", NewFileSink(filepath.Join(dir, "webhooks.jsonl"))).
+		Add("channel", ChannelSink(ch)).
+		Add("order", WebhookSinkFunc(func(wh Webhook) error {
+			if down {
+				return errors.New("service unavailable")
+			}
+			return nil
+		}))
+
+	wh := Webhook{}
+	wh.TrackingNumber = "LX123456789CN"
+	if err = fanOut.Send(wh); err != nil {
+		t.Fatal(err)
+	}
+	fanOut.Wait()
+	if (<-ch).TrackingNumber != wh.TrackingNumber {
+		t.Error("channel sink should receive webhook")
+	}
+	f, _ := os.Open(filepath.Join(dir, "webhooks.jsonl"))
+	defer f.Close()
+	if lines := bufio.NewScanner(f); !lines.Scan() {
+		t.Error("file sink should write webhook")
+	}
+
+	if n, _ := queue.Len(); n != 1 {
+		t.Fatalf("expected 1 queued webhook, got %d", n)
+	}
+	if err = fanOut.Retry(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := queue.Len(); n != 1 {
+		t.Fatalf("failed webhook should stay in queue, got %d", n)
+	}
+	down = false
+	if err = fanOut.Retry(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := queue.Len(); n != 0 {
+		t.Errorf("expected empty queue, got %d", n)
+	}
+}
+
+func TestFanOut_SendAsync(t *testing.T) {
+	queue, err := NewRetryQueue(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	fanOut := NewFanOut(queue).Add("slow", WebhookSinkFunc(func(wh Webhook) error {
+		<-release
+		return nil
+	}))
+
+	sent := make(chan error)
+	go func() { sent <- fanOut.Send(Webhook{}) }()
+	select {
+	case err = <-sent:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send should not wait for the sink")
+	}
+	if n, _ := queue.Len(); n != 1 {
+		t.Errorf("webhook should be persisted before delivery, got %d entries", n)
+	}
+	close(release)
+	fanOut.Wait()
+	if n, _ := queue.Len(); n != 0 {
+		t.Errorf("expected empty queue, got %d", n)
+	}
+}
+
+func TestRetryQueue_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	queue, err := NewRetryQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "00000000000000000000-0000000000.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = queue.Push("order", Webhook{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	sent := 0
+	err = queue.Retry(map[string]WebhookSink{
+		"order": WebhookSinkFunc(func(wh Webhook) error {
+			sent++
+			return nil
+		}),
+	})
+	if err == nil {
+		t.Error("expected corrupt entry error")
+	}
+	if sent != 1 {
+		t.Errorf("expected 1 sent webhook, got %d", sent)
+	}
+	if n, _ := queue.Len(); n != 0 {
+		t.Errorf("expected empty queue, got %d", n)
+	}
+	if n, _ := queue.DeadLen(); n != 1 {
+		t.Errorf("expected 1 dead letter, got %d", n)
+	}
+}
+
+func TestRetryQueue_DeadLetter(t *testing.T) {
+	queue, err := NewRetryQueue(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue.SetMaxAttempts(2)
+	sinks := map[string]WebhookSink{
+		"order": WebhookSinkFunc(func(wh Webhook) error {
+			return errors.New("service unavailable")
+		}),
+	}
+	for i := 0; i < 3; i++ {
+		if err = queue.Push("order", Webhook{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := queue.Len(); n != 3 {
+		t.Fatalf("pushes at the same time should not overwrite each other, got %d entries", n)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = queue.Retry(sinks); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := queue.Len(); n != 0 {
+		t.Errorf("expected empty queue, got %d", n)
+	}
+	if n, _ := queue.DeadLen(); n != 3 {
+		t.Errorf("expected 3 dead letters, got %d", n)
+	}
+
+	if err = queue.Requeue(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := queue.Len(); n != 3 {
+		t.Errorf("expected 3 requeued webhooks, got %d", n)
+	}
+	if n, _ := queue.DeadLen(); n != 0 {
+		t.Errorf("expected no dead letters, got %d", n)
+	}
+}
+
+func TestRetryQueue_PushDuringRetry(t *testing.T) {
+	queue, err := NewRetryQueue(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = queue.Push("order", Webhook{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sending, release := make(chan struct{}), make(chan struct{})
+	sinks := map[string]WebhookSink{
+		"order": WebhookSinkFunc(func(wh Webhook) error {
+			close(sending)
+			<-release
+			return nil
+		}),
+	}
+	done := make(chan error)
+	go func() { done <- queue.Retry(sinks) }()
+
+	<-sending
+	pushed := make(chan error)
+	go func() { pushed <- queue.Push("order", Webhook{}, nil) }()
+	select {
+	case err = <-pushed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Push should not be blocked by Retry")
+	}
+	close(release)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := queue.Len(); n != 1 {
+		t.Errorf("expected 1 queued webhook, got %d", n)
+	}
+}
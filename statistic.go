@@ -0,0 +1,162 @@
+package tracking51
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 包裹状态统计时间序列
+
+// StatisticInterval 统计的时间间隔
+type StatisticInterval string
+
+const (
+	DayInterval  StatisticInterval = "day"  // 按天
+	WeekInterval StatisticInterval = "week" // 按周（周一开始）
+)
+
+// StatisticDateField 统计使用的时间字段
+type StatisticDateField string
+
+const (
+	CreatedDateField  StatisticDateField = "created"  // 创建查询的时间
+	ShippingDateField StatisticDateField = "shipping" // 发货时间
+)
+
+// StatusSeriesOptions 时间序列选项
+type StatusSeriesOptions struct {
+	Interval  StatisticInterval  // 时间间隔，默认为按天
+	DateField StatisticDateField // 时间字段，默认为创建查询的时间
+	Couriers  []string           // 物流商简码，为空时统计所有物流商
+	Language  string             // 错误信息的语言（可以使用 Tracking51.Language()），默认为中文
+}
+
+// StatusSeriesPoint 时间序列中的一个数据点
+type StatusSeriesPoint struct {
+	Start       time.Time `json:"start"`        // 开始时间
+	End         time.Time `json:"end"`          // 结束时间（包含）
+	CourierCode string    `json:"courier_code"` // 物流商简码，为空表示所有物流商
+	StatusStatistic
+}
+
+// StatusSeries 时间序列
+type StatusSeries struct {
+	Interval StatisticInterval   `json:"interval"`
+	Points   []StatusSeriesPoint `json:"points"` // 按开始时间、物流商排序
+}
+
+// StatusSeriesBuilder 将时间范围按天或者周拆分，按时间段和物流商分别调用 StatusStatistic 统计
+//
+// 已经结束的时间段的统计结果会被缓存，再次统计时不会重复请求。
+type StatusSeriesBuilder struct {
+	tracking TrackingService
+	options  StatusSeriesOptions
+	mu       sync.Mutex
+	cache    map[string]StatusStatistic
+	now      func() time.Time
+}
+
+func NewStatusSeriesBuilder(tracking TrackingService, options StatusSeriesOptions) *StatusSeriesBuilder {
+	if options.Interval != WeekInterval {
+		options.Interval = DayInterval
+	}
+	if options.DateField != ShippingDateField {
+		options.DateField = CreatedDateField
+	}
+	if len(options.Couriers) == 0 {
+		options.Couriers = []string{""}
+	}
+	return &StatusSeriesBuilder{
+		tracking: tracking,
+		options:  options,
+		cache:    make(map[string]StatusStatistic),
+		now:      time.Now,
+	}
+}
+
+// 时间所在时间段的开始时间
+func (b *StatusSeriesBuilder) bucketStart(t time.Time) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if b.options.Interval == WeekInterval {
+		t = t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+	return t
+}
+
+func (b *StatusSeriesBuilder) nextBucketStart(t time.Time) time.Time {
+	if b.options.Interval == WeekInterval {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func (b *StatusSeriesBuilder) request(courierCode string, start, end time.Time) StatusStatisticRequest {
+	req := StatusStatisticRequest{CourierCode: courierCode}
+	if b.options.DateField == ShippingDateField {
+		req.ShippingDateMin, req.ShippingDateMax = start.Unix(), end.Unix()
+	} else {
+		req.CreatedDateMin, req.CreatedDateMax = start.Unix(), end.Unix()
+	}
+	return req
+}
+
+// Build 统计 [from, to] 时间范围内的数据，第一个和最后一个时间段会被截取到 from 和 to
+func (b *StatusSeriesBuilder) Build(from, to time.Time) (series StatusSeries, err error) {
+	if to.Before(from) {
+		return series, languageErrorf(b.options.Language, "time_range_invalid", nil)
+	}
+	series.Interval = b.options.Interval
+	now := b.now()
+	for start := b.bucketStart(from); !start.After(to); start = b.nextBucketStart(start) {
+		end := b.nextBucketStart(start).Add(-time.Second)
+		closed := end.Before(now)
+		s, e := start, end
+		if s.Before(from) {
+			s = from
+		}
+		if e.After(to) {
+			e = to
+		}
+		for _, courierCode := range b.options.Couriers {
+			key := courierCode + "|" + strconv.FormatInt(s.Unix(), 10) + "|" + strconv.FormatInt(e.Unix(), 10)
+			b.mu.Lock()
+			stat, ok := b.cache[key]
+			b.mu.Unlock()
+			if !ok {
+				stat, err = b.tracking.StatusStatistic(b.request(courierCode, s, e))
+				if err != nil {
+					return
+				}
+				if closed {
+					b.mu.Lock()
+					b.cache[key] = stat
+					b.mu.Unlock()
+				}
+			}
+			series.Points = append(series.Points, StatusSeriesPoint{Start: s, End: e, CourierCode: courierCode, StatusStatistic: stat})
+		}
+	}
+	return
+}
+
+// WriteCSV 输出为 CSV 格式，每个时间段和物流商一行
+func (s StatusSeries) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"start", "end", "courier_code", "pending", "notfound", "transit", "pickup", "delivered", "expired", "undelivered", "exception", "info_received"}); err != nil {
+		return err
+	}
+	for _, p := range s.Points {
+		row := []string{p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339), p.CourierCode}
+		for _, n := range []int{p.Pending, p.NotFound, p.Transit, p.Pickup, p.Delivered, p.Expired, p.Undelivered, p.Exception, p.InfoReceived} {
+			row = append(row, strconv.Itoa(n))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,64 @@
+package tracking51
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusSeriesBuilder_Build(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodGet || r.URL.Path != "/status" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		q := r.URL.Query()
+		min, _ := strconv.ParseInt(q.Get("created_date_min"), 10, 64)
+		max, _ := strconv.ParseInt(q.Get("created_date_max"), 10, 64)
+		if min == 0 || max < min {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		delivered := 1
+		if q.Get("courier_code") == "dhl" {
+			delivered = 2
+		}
+		writeTestResponse(w, StatusStatistic{Delivered: delivered, Transit: int(max-min+1) / 86400})
+	})
+
+	now := time.Date(2022, 7, 13, 12, 0, 0, 0, time.Local)
+	builder := NewStatusSeriesBuilder(c.Services.Tracking, StatusSeriesOptions{Interval: WeekInterval, Couriers: []string{"usps", "dhl"}})
+	builder.now = func() time.Time { return now }
+	// 2022-07-01 是周五，2022-07-13 是周三
+	from, to := time.Date(2022, 7, 1, 0, 0, 0, 0, time.Local), time.Date(2022, 7, 13, 23, 59, 59, 0, time.Local)
+	series, err := builder.Build(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Points) != 6 || requests != 6 {
+		t.Fatalf("expected 6 points and 6 requests, got %d points, %d requests", len(series.Points), requests)
+	}
+	first, last := series.Points[0], series.Points[5]
+	if !first.Start.Equal(from) || first.End.Format("2006-01-02 15:04:05") != "2022-07-03 23:59:59" || first.CourierCode != "usps" || first.Transit != 3 {
+		t.Errorf("unexpected first point: %#v", first)
+	}
+	if last.Start.Format("2006-01-02") != "2022-07-11" || !last.End.Equal(to) || last.CourierCode != "dhl" || last.Delivered != 2 {
+		t.Errorf("unexpected last point: %#v", last)
+	}
+
+	// 已经结束的时间段有缓存，只会重新请求当前时间段
+	if _, err = builder.Build(from, to); err != nil || requests != 8 {
+		t.Errorf("expected 8 requests, got %d, error: %v", requests, err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = series.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 7 || !strings.HasPrefix(lines[0], "start,end,courier_code,") {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,53 @@
+package tracking51
+
+import "strings"
+
+// 包裹状态目录
+
+// DeliveryStatus 包裹状态说明
+type DeliveryStatus struct {
+	Code        string // 状态
+	Name        string // 中文名称
+	EnglishName string // 英文名称
+	Description string // 说明
+}
+
+var deliveryStatuses = []DeliveryStatus{
+	{StatusPending, "查询中", "Pending", "新增包裹正在查询中，请等待"},
+	{StatusNotFound, "查询不到", "Not Found", "包裹信息目前查询不到"},
+	{StatusTransit, "运输途中", "In Transit", "物流商已揽件，包裹正被发往目的地"},
+	{StatusPickup, "到达待取", "Pick Up", "包裹正在派送中，或到达当地收发点"},
+	{StatusDelivered, "成功签收", "Delivered", "包裹已被成功投递"},
+	{StatusExpired, "运输过久", "Expired", "包裹在很长时间内都未投递成功"},
+	{StatusUndelivered, "投递失败", "Undelivered", "快递员投递失败（通常会留有通知并再次尝试投递）"},
+	{StatusException, "可能异常", "Exception", "包裹退回、包裹丢失、清关失败等异常情况"},
+	{StatusInfoReceived, "待上网", "Info Received", "包裹正在等待被揽件"},
+}
+
+// DeliveryStatuses 返回所有的包裹状态
+func DeliveryStatuses() []DeliveryStatus {
+	return append([]DeliveryStatus(nil), deliveryStatuses...)
+}
+
+// LookupDeliveryStatus 根据状态值查找包裹状态说明
+func LookupDeliveryStatus(code string) (DeliveryStatus, bool) {
+	code = strings.ToLower(code)
+	for _, status := range deliveryStatuses {
+		if status.Code == code {
+			return status, true
+		}
+	}
+	return DeliveryStatus{}, false
+}
+
+// DeliveryStatusName 返回包裹状态的名称（cn, en），未知的状态返回原值
+func DeliveryStatusName(code, lang string) string {
+	status, ok := LookupDeliveryStatus(code)
+	if !ok {
+		return code
+	}
+	if strings.ToLower(lang) == EnglishLanguage {
+		return status.EnglishName
+	}
+	return status.Name
+}
@@ -0,0 +1,386 @@
+package tracking51
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// 包裹数据本地存储
+
+// SyncState 同步状态
+type SyncState struct {
+	Watermark int64 `json:"watermark"`  // 最后一次完成同步的时间（时间戳格式），下次同步从该时间开始
+	WindowMin int64 `json:"window_min"` // 同步中的更新开始时间（时间戳格式），为 0 表示全量同步
+	WindowMax int64 `json:"window_max"` // 同步中的更新结束时间（时间戳格式），为 0 表示当前没有进行中的同步
+	Cursor    int64 `json:"cursor"`     // 同步中下一个子窗口的更新开始时间（时间戳格式）
+}
+
+// Store 包裹数据存储接口
+type Store interface {
+	Get(trackingNumber, courierCode string) (track Track, exists bool, err error) // 获取包裹
+	Save(track Track) error                                                       // 保存包裹（存在则更新），并合并物流节点历史
+	Tracks() ([]Track, error)                                                     // 所有包裹
+	Checkpoints(trackingNumber, courierCode string) ([]TrackInformation, error)   // 包裹的物流节点历史（按时间升序）
+	State() (SyncState, error)                                                    // 同步状态
+	SaveState(state SyncState) error                                              // 保存同步状态
+}
+
+// BatchStore 支持一次保存多个包裹的存储，同步时每个子窗口只写入一次
+type BatchStore interface {
+	SaveTracks(tracks []Track) error
+}
+
+func trackKey(trackingNumber, courierCode string) string {
+	return trackingNumber + "|" + courierCode
+}
+
+func checkpointKey(c TrackInformation) string {
+	return c.CheckpointDate + "|" + c.Location + "|" + c.TrackingDetail
+}
+
+// 合并物流节点历史，已存在的节点不会重复添加
+func mergeCheckpoints(history []TrackInformation, track Track) []TrackInformation {
+	keys := make(map[string]struct{}, len(history))
+	for _, c := range history {
+		keys[checkpointKey(c)] = struct{}{}
+	}
+	for _, info := range []Information{track.OriginInfo, track.DestinationInfo} {
+		for _, c := range info.TrackInfo {
+			key := checkpointKey(c)
+			if _, ok := keys[key]; !ok {
+				keys[key] = struct{}{}
+				history = append(history, c)
+			}
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		ti, _ := parseTime(history[i].CheckpointDate)
+		tj, _ := parseTime(history[j].CheckpointDate)
+		return ti.Before(tj)
+	})
+	return history
+}
+
+// 判断 b 是否比 a 更新（根据系统最后更新查询的时间判断）
+func isNewerTrack(a, b Track) bool {
+	ta, ok := parseTime(a.UpdateDate)
+	if !ok {
+		return true
+	}
+	tb, ok := parseTime(b.UpdateDate)
+	return !ok || !tb.Before(ta)
+}
+
+type storeData struct {
+	Tracks      map[string]Track
+	Checkpoints map[string][]TrackInformation
+	State       SyncState
+}
+
+func newStoreData() storeData {
+	return storeData{
+		Tracks:      make(map[string]Track),
+		Checkpoints: make(map[string][]TrackInformation),
+	}
+}
+
+func (d *storeData) save(track Track) {
+	key := trackKey(track.TrackingNumber, track.CourierCode)
+	if old, ok := d.Tracks[key]; !ok || isNewerTrack(old, track) {
+		d.Tracks[key] = track
+	}
+	d.Checkpoints[key] = mergeCheckpoints(d.Checkpoints[key], track)
+}
+
+func (d *storeData) tracks() []Track {
+	keys := make([]string, 0, len(d.Tracks))
+	for key := range d.Tracks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tracks := make([]Track, len(keys))
+	for i, key := range keys {
+		tracks[i] = d.Tracks[key]
+	}
+	return tracks
+}
+
+// MemoryStore 内存存储
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data storeData
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: newStoreData()}
+}
+
+func (s *MemoryStore) Get(trackingNumber, courierCode string) (track Track, exists bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	track, exists = s.data.Tracks[trackKey(trackingNumber, courierCode)]
+	return
+}
+
+func (s *MemoryStore) Save(track Track) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.save(track)
+	return nil
+}
+
+func (s *MemoryStore) SaveTracks(tracks []Track) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, track := range tracks {
+		s.data.save(track)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Tracks() ([]Track, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.tracks(), nil
+}
+
+func (s *MemoryStore) Checkpoints(trackingNumber, courierCode string) ([]TrackInformation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]TrackInformation(nil), s.data.Checkpoints[trackKey(trackingNumber, courierCode)]...), nil
+}
+
+func (s *MemoryStore) State() (SyncState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.State, nil
+}
+
+func (s *MemoryStore) SaveState(state SyncState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.State = state
+	return nil
+}
+
+// FileStore 文件存储
+//
+// 包裹以 JSON Lines 格式追加到日志文件（path）中，同步状态单独保存在 path + ".state" 文件中，
+// 保存包裹时只追加新的记录，保存同步状态时不会重写包裹数据。打开存储时会重放日志恢复数据，
+// 日志中过期的记录较多时会压缩日志（每个包裹只保留一条记录）。进程崩溃导致的不完整的最后一行会被忽略。
+type FileStore struct {
+	mu      sync.RWMutex
+	path    string
+	data    storeData
+	records int // 日志中的记录数量
+}
+
+// 日志记录
+type storeRecord struct {
+	Track       Track              `json:"track"`
+	Checkpoints []TrackInformation `json:"checkpoints,omitempty"` // 物流节点历史（只有压缩日志时写入）
+}
+
+// 日志中的记录数量超过包裹数量的 2 倍（并且不少于该值）时压缩日志
+const fileStoreCompactThreshold = 1024
+
+// NewFileStore 打开文件存储，文件不存在时会自动创建
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, data: newStoreData()}
+	b, err := os.ReadFile(path + ".state")
+	if err == nil {
+		if len(b) != 0 {
+			if err = json.Unmarshal(b, &s.data.State); err != nil {
+				return nil, err
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	partial := false
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			var record storeRecord
+			if e := json.Unmarshal(line, &record); e != nil {
+				if err == io.EOF {
+					// 写入过程中崩溃导致的不完整记录
+					partial = true
+					break
+				}
+				return nil, e
+			}
+			s.replay(record)
+			s.records++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if partial || s.needCompact() {
+		if err = s.compact(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// writeFileAtomic 先写入临时文件再替换目标文件，保证文件内容完整
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// 重放日志记录
+func (s *FileStore) replay(record storeRecord) {
+	if len(record.Checkpoints) != 0 {
+		key := trackKey(record.Track.TrackingNumber, record.Track.CourierCode)
+		s.data.Checkpoints[key] = mergeCheckpoints(s.data.Checkpoints[key], Track{OriginInfo: Information{TrackInfo: record.Checkpoints}})
+	}
+	s.data.save(record.Track)
+}
+
+func (s *FileStore) needCompact() bool {
+	return s.records >= fileStoreCompactThreshold && s.records > 2*len(s.data.Tracks)
+}
+
+// 压缩日志，每个包裹只保留一条记录（包括物流节点历史）
+func (s *FileStore) compact() error {
+	var buf bytes.Buffer
+	tracks := s.data.tracks()
+	for _, track := range tracks {
+		b, err := json.Marshal(storeRecord{Track: track, Checkpoints: s.data.Checkpoints[trackKey(track.TrackingNumber, track.CourierCode)]})
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	if err := writeFileAtomic(s.path, buf.Bytes()); err != nil {
+		return err
+	}
+	s.records = len(tracks)
+	return nil
+}
+
+// 追加日志记录
+func (s *FileStore) append(tracks []Track) error {
+	var buf bytes.Buffer
+	for _, track := range tracks {
+		b, err := json.Marshal(storeRecord{Track: track})
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(buf.Bytes()); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	for _, track := range tracks {
+		s.data.save(track)
+	}
+	s.records += len(tracks)
+	if s.needCompact() {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *FileStore) Get(trackingNumber, courierCode string) (track Track, exists bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	track, exists = s.data.Tracks[trackKey(trackingNumber, courierCode)]
+	return
+}
+
+func (s *FileStore) Save(track Track) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append([]Track{track})
+}
+
+// SaveTracks 保存多个包裹，只写入一次文件
+func (s *FileStore) SaveTracks(tracks []Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(tracks)
+}
+
+func (s *FileStore) Tracks() ([]Track, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.tracks(), nil
+}
+
+func (s *FileStore) Checkpoints(trackingNumber, courierCode string) ([]TrackInformation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]TrackInformation(nil), s.data.Checkpoints[trackKey(trackingNumber, courierCode)]...), nil
+}
+
+func (s *FileStore) State() (SyncState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.State, nil
+}
+
+// SaveState 保存同步状态，只写入状态文件
+func (s *FileStore) SaveState(state SyncState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = writeFileAtomic(s.path+".state", b); err == nil {
+		s.data.State = state
+	}
+	return err
+}
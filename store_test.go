@@ -0,0 +1,71 @@
+package tracking51
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracks.jsonl")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := Track{TrackingNumber: "A", CourierCode: "usps", UpdateDate: "2022-07-01 10:00:00", OriginInfo: Information{TrackInfo: []TrackInformation{
+		{CheckpointDate: "2022-07-01 09:00:00", TrackingDetail: "Accepted"},
+	}}}
+	second := Track{TrackingNumber: "A", CourierCode: "usps", UpdateDate: "2022-07-02 10:00:00", OriginInfo: Information{TrackInfo: []TrackInformation{
+		{CheckpointDate: "2022-07-02 09:00:00", TrackingDetail: "In transit"},
+	}}}
+	if err = store.Save(first); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.SaveTracks([]Track{second, {TrackingNumber: "B", CourierCode: "usps"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 保存同步状态时不会重写包裹数据
+	info, _ := os.Stat(path)
+	if err = store.SaveState(SyncState{Watermark: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if latest, _ := os.Stat(path); latest.Size() != info.Size() || !latest.ModTime().Equal(info.ModTime()) {
+		t.Error("SaveState should not rewrite the track log")
+	}
+
+	// 模拟写入过程中崩溃
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"track":{"tracking_number":"C"`)
+	f.Close()
+
+	check := func(store *FileStore) {
+		t.Helper()
+		tracks, _ := store.Tracks()
+		if len(tracks) != 2 {
+			t.Errorf("expected 2 tracks, got %d", len(tracks))
+		}
+		if track, _, _ := store.Get("A", "usps"); track.UpdateDate != second.UpdateDate {
+			t.Errorf("expected the newer track, got %#v", track)
+		}
+		if checkpoints, _ := store.Checkpoints("A", "usps"); len(checkpoints) != 2 {
+			t.Errorf("expected 2 checkpoints, got %d", len(checkpoints))
+		}
+		if state, _ := store.State(); state.Watermark != 100 {
+			t.Errorf("unexpected state: %#v", state)
+		}
+	}
+	if store, err = NewFileStore(path); err != nil {
+		t.Fatal(err)
+	}
+	check(store)
+	if store.records != 2 {
+		t.Errorf("expected compacted log with 2 records, got %d", store.records)
+	}
+
+	// 压缩后仍然保留物流节点历史
+	if store, err = NewFileStore(path); err != nil {
+		t.Fatal(err)
+	}
+	check(store)
+}
@@ -0,0 +1,176 @@
+package tracking51
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// 增量同步
+
+// SyncOptions 同步选项
+type SyncOptions struct {
+	PageSize  int                    // 每页查询的单号个数，默认为 100
+	ClockSkew time.Duration          // 时钟偏差容忍时间，每次同步的开始时间会在上次同步结束时间的基础上往前推移该时长，默认为 10 分钟
+	OnEvent   func(event TrackEvent) // 包裹有变化时的回调（新增的包裹与空数据比较）
+	Logger    *log.Logger            // 日志记录器（可以使用 Tracking51.Logger()），默认输出到标准输出
+}
+
+// Syncer 将 51Tracking 的包裹数据增量同步到本地存储中
+//
+// 每次同步会查询 [上次同步结束时间 - ClockSkew, 当前时间] 内更新过的包裹。时间窗口按更新时间拆分为多个子窗口依次查询，
+// 子窗口内的包裹超过一页时缩小子窗口，每个子窗口只查询一页，不使用分页偏移，因此同步期间有包裹被更新并移出时间窗口时，
+// 不会导致其后的包裹被跳过（移出的包裹在下一次同步的窗口中获取）。只有同一秒内更新的包裹超过一页时才会分页查询。
+// 同步进度（时间窗口和下一个子窗口的开始时间）在每个子窗口处理完成后保存，进程崩溃后再次同步时会从中断的位置继续。
+type Syncer struct {
+	tracking TrackingService
+	store    Store
+	options  SyncOptions
+	now      func() time.Time
+}
+
+func NewSyncer(tracking TrackingService, store Store, options SyncOptions) *Syncer {
+	if options.PageSize <= 0 {
+		options.PageSize = 100
+	}
+	if options.ClockSkew <= 0 {
+		options.ClockSkew = 10 * time.Minute
+	}
+	if options.Logger == nil {
+		options.Logger = defaultLogger
+	}
+	return &Syncer{
+		tracking: tracking,
+		store:    store,
+		options:  options,
+		now:      time.Now,
+	}
+}
+
+// 保存一个子窗口的包裹，存储实现了 BatchStore 时一次写入
+func (s *Syncer) save(items []Track) error {
+	if s.options.OnEvent != nil {
+		for _, item := range items {
+			old, _, err := s.store.Get(item.TrackingNumber, item.CourierCode)
+			if err != nil {
+				return err
+			}
+			if event := NewTrackEvent(PollingEventSource, old, item); event.HasChanges() {
+				s.options.OnEvent(event)
+			}
+		}
+	}
+	if bs, ok := s.store.(BatchStore); ok {
+		return bs.SaveTracks(items)
+	}
+	for _, item := range items {
+		if err := s.store.Save(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 分页查询同一秒内更新的所有包裹
+func (s *Syncer) queryAll(params TracksQueryParams) (tracks []Track, err error) {
+	for {
+		items, isLastPage, e := s.tracking.Query(params)
+		if e != nil {
+			return tracks, e
+		}
+		tracks = append(tracks, items...)
+		if isLastPage {
+			return
+		}
+		params.PagesAmount++
+	}
+}
+
+// Sync 执行一次同步，返回同步的包裹数量
+func (s *Syncer) Sync() (n int, err error) {
+	state, err := s.store.State()
+	if err != nil {
+		return
+	}
+
+	if state.WindowMax == 0 {
+		// 开始新的同步窗口，首次同步（没有 Watermark）时全量同步
+		state.WindowMin = 0
+		if state.Watermark > 0 {
+			state.WindowMin = state.Watermark - int64(s.options.ClockSkew/time.Second)
+			if state.WindowMin <= 0 {
+				state.WindowMin = 1
+			}
+		}
+		state.WindowMax = s.now().Unix()
+		state.Cursor = 0
+		if err = s.store.SaveState(state); err != nil {
+			return
+		}
+	}
+	if state.Cursor < state.WindowMin {
+		state.Cursor = state.WindowMin
+	}
+	if state.Cursor <= 0 {
+		state.Cursor = 1
+	}
+
+	width := state.WindowMax - state.Cursor
+	for state.Cursor <= state.WindowMax {
+		end := state.Cursor + width
+		if end > state.WindowMax {
+			end = state.WindowMax
+		}
+		params := TracksQueryParams{
+			ItemsAmount:    s.options.PageSize,
+			PagesAmount:    1,
+			UpdatedDateMin: state.Cursor,
+			UpdatedDateMax: end,
+		}
+		items, isLastPage, e := s.tracking.Query(params)
+		if e != nil {
+			return n, e
+		}
+		if !isLastPage {
+			if end > state.Cursor {
+				// 子窗口内的包裹超过一页，缩小子窗口
+				width = (end - state.Cursor) / 2
+				continue
+			}
+			if items, e = s.queryAll(params); e != nil {
+				return n, e
+			}
+		}
+
+		if err = s.save(items); err != nil {
+			return
+		}
+		n += len(items)
+		// 下一个子窗口从当前子窗口宽度的两倍开始尝试
+		width = 2*(end-state.Cursor) + 1
+		state.Cursor = end + 1
+		if err = s.store.SaveState(state); err != nil {
+			return
+		}
+	}
+
+	err = s.store.SaveState(SyncState{Watermark: state.WindowMax})
+	return
+}
+
+// Run 按指定的时间间隔持续同步，直到 ctx 被取消
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sync(); err != nil {
+			s.options.Logger.Printf("Sync error: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tracking51
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 按更新时间过滤并分页返回包裹，模拟 51Tracking 的查询接口
+type syncTestServer struct {
+	mu      sync.Mutex
+	tracks  []Track
+	queries int
+	onQuery func(queries int)
+}
+
+func (s *syncTestServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.queries++
+	if s.onQuery != nil {
+		s.onQuery(s.queries)
+	}
+	q := r.URL.Query()
+	min, _ := strconv.ParseInt(q.Get("updated_date_min"), 10, 64)
+	max, _ := strconv.ParseInt(q.Get("updated_date_max"), 10, 64)
+	size, _ := strconv.Atoi(q.Get("items_amount"))
+	page, _ := strconv.Atoi(q.Get("pages_amount"))
+	var matched []Track
+	for _, track := range s.tracks {
+		updatedAt, _ := parseTime(track.UpdateDate)
+		if min == 0 || (updatedAt.Unix() >= min && updatedAt.Unix() <= max) {
+			matched = append(matched, track)
+		}
+	}
+	s.mu.Unlock()
+
+	start := (page - 1) * size
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + size
+	if end > len(matched) {
+		end = len(matched)
+	}
+	writeTestResponse(w, matched[start:end])
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	updated := func(seconds int) string {
+		return base.Add(time.Duration(seconds) * time.Second).Format("2006-01-02 15:04:05")
+	}
+	server := &syncTestServer{tracks: []Track{
+		{TrackingNumber: "A", CourierCode: "usps", UpdateDate: updated(0), OriginInfo: Information{TrackInfo: []TrackInformation{{CheckpointDate: "2022-07-01 10:00:00", TrackingDetail: "Accepted"}}}},
+		{TrackingNumber: "B", CourierCode: "usps", UpdateDate: updated(10)},
+		{TrackingNumber: "C", CourierCode: "usps", UpdateDate: updated(20)},
+		{TrackingNumber: "D", CourierCode: "usps", UpdateDate: updated(20)},
+		{TrackingNumber: "E", CourierCode: "usps", UpdateDate: updated(20)},
+		{TrackingNumber: "F", CourierCode: "usps", UpdateDate: updated(30)},
+	}}
+	// 同步期间 A 被更新并移出时间窗口，其后的包裹不能被跳过
+	server.onQuery = func(queries int) {
+		if queries == 2 {
+			server.tracks[0].UpdateDate = time.Now().Add(time.Hour).Format("2006-01-02 15:04:05")
+		}
+	}
+	c := newTestClient(t, server.handle)
+
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncer := NewSyncer(c.Services.Tracking, store, SyncOptions{PageSize: 2})
+	if _, err = syncer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := store.State()
+	if state.Watermark == 0 || state.WindowMax != 0 {
+		t.Errorf("unexpected state: %#v", state)
+	}
+
+	// 重新打开存储，检查数据是否持久化
+	store, err = NewFileStore(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, number := range []string{"B", "C", "D", "E", "F"} {
+		if _, exists, _ := store.Get(number, "usps"); !exists {
+			t.Errorf("track %s should be synced", number)
+		}
+	}
+
+	// A 在下一次同步的窗口中获取
+	syncer = NewSyncer(c.Services.Tracking, store, SyncOptions{PageSize: 2})
+	syncer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err = syncer.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	checkpoints, _ := store.Checkpoints("A", "usps")
+	if _, exists, _ := store.Get("A", "usps"); !exists || len(checkpoints) != 1 {
+		t.Errorf("track A should be synced in the next window, checkpoints: %d", len(checkpoints))
+	}
+}
+
+func TestSyncer_SyncResume(t *testing.T) {
+	server := &syncTestServer{tracks: []Track{
+		{TrackingNumber: "A", CourierCode: "usps", UpdateDate: time.Now().Add(-time.Minute).Format("2006-01-02 15:04:05")},
+	}}
+	c := newTestClient(t, server.handle)
+	store := NewMemoryStore()
+	now := time.Now().Unix()
+	// 上次同步在处理完 [now-3600, now-120] 后中断
+	store.SaveState(SyncState{Watermark: now - 7200, WindowMin: now - 3600, WindowMax: now, Cursor: now - 119})
+	n, err := NewSyncer(c.Services.Tracking, store, SyncOptions{}).Sync()
+	if err != nil || n != 1 || server.queries != 1 {
+		t.Errorf("expected 1 track in 1 query, got %d tracks in %d queries, error: %v", n, server.queries, err)
+	}
+	if state, _ := store.State(); state.Watermark != now {
+		t.Errorf("unexpected state: %#v", state)
+	}
+}
@@ -0,0 +1,167 @@
+package tracking51
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 合并发件国和目的国的物流节点
+
+// TimelineEvent 物流节点
+type TimelineEvent struct {
+	TrackInformation
+	Source      string    `json:"source"`       // 来源（origin, destination）
+	CourierCode string    `json:"courier_code"` // 来源物流商简码
+	Time        time.Time `json:"time"`         // 解析后的节点时间（无法解析时为零值）
+}
+
+// TimelineOptions 合并选项
+type TimelineOptions struct {
+	Window     time.Duration // 时间相差在该范围内的节点才会被认为是重复节点，默认为 1 小时
+	Similarity float64       // 节点详情的相似度（0~1）达到该值才会被认为是重复节点，默认为 0.8
+	Descending bool          // 是否按时间倒序排列（最新的在前）
+}
+
+// 将文字拆分为词（中日韩文字每个字作为一个词）
+func textTokens(s string) map[string]struct{} {
+	tokens := make(map[string]struct{})
+	var word []rune
+	flush := func() {
+		if len(word) != 0 {
+			tokens[string(word)] = struct{}{}
+			word = word[:0]
+		}
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens[string(r)] = struct{}{}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// textSimilarity 计算两段文字的相似度（Jaccard 系数）
+func textSimilarity(a, b string) float64 {
+	ta, tb := textTokens(a), textTokens(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+	n := 0
+	for token := range ta {
+		if _, ok := tb[token]; ok {
+			n++
+		}
+	}
+	return float64(n) / float64(len(ta)+len(tb)-n)
+}
+
+// MergeTimeline 合并发件国和目的国的物流节点，并按时间排列
+//
+// 包裹交接给目的国物流商后，两者返回的物流节点会有重叠，时间相近且详情相似的来自不同物流商的节点只保留先出现的一个（发件国优先）。
+// 无法解析时间的节点排在最后，并保持原有顺序。
+func MergeTimeline(track Track, options TimelineOptions) []TimelineEvent {
+	if options.Window <= 0 {
+		options.Window = time.Hour
+	}
+	if options.Similarity <= 0 {
+		options.Similarity = 0.8
+	}
+
+	var events []TimelineEvent
+	for _, v := range []struct {
+		source string
+		info   Information
+	}{{"origin", track.OriginInfo}, {"destination", track.DestinationInfo}} {
+		courierCode := v.info.CourierCode
+		if courierCode == "" && v.source == "origin" {
+			courierCode = track.CourierCode
+		}
+		for _, ti := range v.info.TrackInfo {
+			t, _ := parseTime(ti.CheckpointDate)
+			events = append(events, TimelineEvent{TrackInformation: ti, Source: v.source, CourierCode: courierCode, Time: t})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time.IsZero() || events[j].Time.IsZero() {
+			return !events[i].Time.IsZero() && events[j].Time.IsZero()
+		}
+		if events[i].Time.Equal(events[j].Time) {
+			return events[i].Source == "origin" && events[j].Source != "origin"
+		}
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	merged := make([]TimelineEvent, 0, len(events))
+	for _, event := range events {
+		duplicate := false
+		if !event.Time.IsZero() {
+			for i := len(merged) - 1; i >= 0; i-- {
+				prev := merged[i]
+				if prev.Time.IsZero() || event.Time.Sub(prev.Time) > options.Window {
+					break
+				}
+				if prev.Source != event.Source && textSimilarity(prev.TrackingDetail, event.TrackingDetail) >= options.Similarity {
+					duplicate = true
+					break
+				}
+			}
+		}
+		if !duplicate {
+			merged = append(merged, event)
+		}
+	}
+
+	if options.Descending {
+		// 无法解析时间的节点仍然排在最后
+		n := len(merged)
+		for n > 0 && merged[n-1].Time.IsZero() {
+			n--
+		}
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			merged[i], merged[j] = merged[j], merged[i]
+		}
+	}
+	return merged
+}
+
+// Milestones 包裹的关键时间点（无法获取时为零值）
+type Milestones struct {
+	Received           time.Time `json:"received"`            // 物流商接收包裹（上网）时间
+	Dispatched         time.Time `json:"dispatched"`          // 封发时间
+	DepartedAirport    time.Time `json:"departed_airport"`    // 离开出发机场时间
+	ArrivedAbroad      time.Time `json:"arrived_abroad"`      // 到达目的国时间
+	CustomsReceived    time.Time `json:"customs_received"`    // 移交海关时间
+	ArrivedDestination time.Time `json:"arrived_destination"` // 到达目的国、目的城市时间
+}
+
+// 返回第一个可以解析的时间
+func firstTime(values ...string) time.Time {
+	for _, v := range values {
+		if t, ok := parseTime(v); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// TrackMilestones 返回包裹的关键时间点，出发相关的时间优先使用发件国物流信息，到达相关的时间优先使用目的国物流信息
+func TrackMilestones(track Track) Milestones {
+	o, d := track.OriginInfo, track.DestinationInfo
+	return Milestones{
+		Received:           firstTime(o.ReceivedDate, d.ReceivedDate),
+		Dispatched:         firstTime(o.DispatchedDate, d.DispatchedDate),
+		DepartedAirport:    firstTime(o.DepartedAirportDate, d.DepartedAirportDate),
+		ArrivedAbroad:      firstTime(d.ArrivedAbroadDate, o.ArrivedAbroadDate),
+		CustomsReceived:    firstTime(d.CustomsReceivedDate, o.CustomsReceivedDate),
+		ArrivedDestination: firstTime(d.ArrivedDestinationDate, o.ArrivedDestinationDate),
+	}
+}
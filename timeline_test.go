@@ -0,0 +1,53 @@
+package tracking51
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeTimeline(t *testing.T) {
+	track := Track{
+		CourierCode: "china-post",
+		OriginInfo: Information{
+			ArrivedDestinationDate: "2022-07-06 09:00:00",
+			ReceivedDate:           "2022-07-01 10:00:00",
+			TrackInfo: []TrackInformation{
+				{CheckpointDate: "2022-07-06 09:00:00", TrackingDetail: "Arrived at destination country, Los Angeles"},
+				{CheckpointDate: "2022-07-01 10:00:00", TrackingDetail: "收寄计费信息"},
+				{CheckpointDate: "", TrackingDetail: "Unknown"},
+			},
+		},
+		DestinationInfo: Information{
+			CourierCode:            "usps",
+			ArrivedDestinationDate: "2022-07-06 09:30:00",
+			TrackInfo: []TrackInformation{
+				{CheckpointDate: "2022-07-08 10:00:00", TrackingDetail: "Delivered"},
+				{CheckpointDate: "2022-07-06 09:30:00", TrackingDetail: "Arrived at destination country - Los Angeles"},
+			},
+		},
+	}
+
+	events := MergeTimeline(track, TimelineOptions{})
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %#v", events)
+	}
+	if events[0].TrackingDetail != "收寄计费信息" || events[0].CourierCode != "china-post" {
+		t.Errorf("unexpected first event: %#v", events[0])
+	}
+	if events[2].TrackingDetail != "Delivered" || events[2].CourierCode != "usps" {
+		t.Errorf("unexpected third event: %#v", events[2])
+	}
+	if events[3].TrackingDetail != "Unknown" {
+		t.Error("events without time should be at the end")
+	}
+
+	events = MergeTimeline(track, TimelineOptions{Descending: true})
+	if events[0].TrackingDetail != "Delivered" || events[3].TrackingDetail != "Unknown" {
+		t.Errorf("unexpected descending events: %#v", events)
+	}
+
+	milestones := TrackMilestones(track)
+	if milestones.Received.IsZero() || milestones.ArrivedDestination.Minute() != 30 || !milestones.CustomsReceived.Equal(time.Time{}) {
+		t.Errorf("unexpected milestones: %#v", milestones)
+	}
+}
@@ -2,11 +2,9 @@ package tracking51
 
 import (
 	"encoding/json"
-	"errors"
-	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
-	"regexp"
+	"io"
 	"strings"
 	"time"
 )
@@ -33,12 +31,22 @@ type CreateTrackRequest struct {
 
 func (m CreateTrackRequest) Validate() error {
 	return validation.ValidateStruct(&m,
-		validation.Field(&m.TrackingNumber, validation.Required.Error("包裹物流单号不能为空")),
-		validation.Field(&m.CourierCode, validation.Required.Error("物流商简码不能为空")),
-		validation.Field(&m.CustomerEmail, validation.When(m.CustomerEmail != "", is.EmailFormat.Error("客户邮箱地址格式错误"))),
-		validation.Field(&m.CustomerPhone, validation.When(m.CustomerPhone != "", validation.Match(regexp.MustCompile(`^+\d{2}\d{11}$`)).Error("客户手机号码格式错误"))),
-		validation.Field(&m.ShippingDate, validation.When(m.ShippingDate != "", validation.Date("2006-01-02 15:04").Error("包裹发货时间格式错误"))),
-		validation.Field(&m.TrackingShippingDate, validation.When(m.TrackingShippingDate != "", validation.Date("20060102").Error("跟踪包裹发货时间格式错误"))),
+		validation.Field(&m.TrackingNumber, validation.Required.ErrorObject(messageError("tracking_number_required"))),
+		validation.Field(&m.CourierCode, validation.Required.ErrorObject(messageError("courier_code_required"))),
+		validation.Field(&m.CustomerEmail, validation.When(m.CustomerEmail != "", is.EmailFormat.ErrorObject(messageError("customer_email_invalid")))),
+		validation.Field(&m.DestinationCode, countryCodeRule("destination_code_invalid")),
+		validation.Field(&m.CustomerPhone, phoneRule("customer_phone_invalid")),
+		validation.Field(&m.ShippingDate, validation.When(m.ShippingDate != "", validation.Date("2006-01-02 15:04").ErrorObject(messageError("shipping_date_invalid")))),
+		validation.Field(&m.TrackingShippingDate,
+			courierRequiredRule(m.CourierCode, TrackingShippingDateField),
+			validation.When(m.TrackingShippingDate != "", validation.Date("20060102").ErrorObject(messageError("tracking_shipping_date_invalid"))),
+		),
+		validation.Field(&m.TrackingPostalCode, courierRequiredRule(m.CourierCode, TrackingPostalCodeField)),
+		validation.Field(&m.TrackingDestinationCode,
+			courierRequiredRule(m.CourierCode, TrackingDestinationCodeField),
+			countryCodeRule("destination_code_invalid"),
+		),
+		validation.Field(&m.TrackingCourierAccount, courierRequiredRule(m.CourierCode, TrackingCourierAccountField)),
 	)
 }
 
@@ -46,10 +54,63 @@ type CreateResult struct {
 	TrackingNumber string `json:"tracking_number"` // 包裹物流单号
 	CourierCode    string `json:"courier_code"`    // 物流商对应的唯一简码
 	OrderNumber    string `json:"order_number"`    // 包裹的订单号，由商家/平台所产生的订单编号
+	ErrorCode      int    `json:"errorCode"`       // 失败时的错误代码
+	ErrorMessage   string `json:"errorMessage"`    // 失败时的错误信息
 }
 
 func (s trackingService) Create(req CreateTrackRequest) (success []CreateResult, error []CreateResult, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
+		return
+	}
+
+	resp, err := s.httpClient.R().SetBody(req).Post("/create")
+	if err != nil {
+		return
+	}
+
+	r := struct {
+		NormalResponse
+		Data struct {
+			Success []CreateResult `json:"success"`
+			Error   []CreateResult `json:"error"`
+		} `json:"data"`
+	}{}
+	if err = json.Unmarshal(resp.Body(), &r); err == nil {
+		success = r.Data.Success
+		error = r.Data.Error
+	}
+	return
+}
+
+// 批量添加物流单号
+
+type CreateTrackRequests []CreateTrackRequest
+
+func (m CreateTrackRequests) Validate() error {
+	if err := validateBatchSize(len(m), 40); err != nil {
+		return err
+	}
+	if _, invalid := m.Partition(); invalid != nil {
+		return invalid
+	}
+	return nil
+}
+
+// Partition 拆分为通过验证和没有通过验证的数据（不检查数量），可以只提交通过验证的数据
+func (m CreateTrackRequests) Partition() (valid CreateTrackRequests, invalid *BatchError) {
+	for i, request := range m {
+		if err := request.Validate(); err != nil {
+			invalid = invalid.add(i, request.TrackingNumber, err)
+		} else {
+			valid = append(valid, request)
+		}
+	}
+	return
+}
+
+// BatchCreate 批量添加物流单号，所有单号都通过验证后才会发送请求
+func (s trackingService) BatchCreate(req CreateTrackRequests) (success []CreateResult, error []CreateResult, err error) {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
@@ -78,7 +139,7 @@ type UpdateTrackRequest = CreateTrackRequest
 type UpdateResult CreateResult
 
 func (s trackingService) Update(req UpdateTrackRequest) (success []UpdateResult, error []UpdateResult, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
@@ -135,6 +196,8 @@ type Track struct {
 	LatestEvent            string      `json:"latest_event"`             // 最新物流信息的梗概，包括以下信息：状态、地址、时间
 	LatestCheckpointTime   string      `json:"lastest_checkpoint_time"`  // 最新物流信息的更新时间
 	OriginInfo             Information `json:"origin_info"`              // 发件国的物流信息
+	DeliveryStatus         string      `json:"delivery_status"`          // 包裹状态
+	SubStatus              string      `json:"substatus"`                // 包裹子状态
 }
 
 type Information struct {
@@ -183,7 +246,7 @@ func checkUnixTime(value interface{}) error {
 	}
 	v, ok := value.(int64)
 	if !ok || time.Unix(v, 0).IsZero() {
-		return errors.New("无效的时间戳值")
+		return messageError("timestamp_invalid")
 	}
 	return nil
 }
@@ -193,58 +256,58 @@ func (m TracksQueryParams) Validate() error {
 		validation.Field(&m.TrackingNumbers, validation.When(m.TrackingNumbers != "", validation.By(func(value interface{}) error {
 			numbers, ok := value.(string)
 			if !ok {
-				return fmt.Errorf("无效的查询单号：%s", m.TrackingNumbers)
+				return messageErrorf("tracking_numbers_invalid", map[string]interface{}{"value": m.TrackingNumbers})
 			}
 			if len(strings.Split(numbers, ",")) > 40 {
-				return errors.New("查询单号不能超过 40 个")
+				return messageError("tracking_numbers_too_many")
 			}
 			return nil
 		}))),
 		validation.Field(&m.OrderNumbers, validation.When(m.OrderNumbers != "", validation.By(func(value interface{}) error {
 			numbers, ok := value.(string)
 			if !ok {
-				return fmt.Errorf("无效的订单号：%s", m.OrderNumbers)
+				return messageErrorf("order_numbers_invalid", map[string]interface{}{"value": m.OrderNumbers})
 			}
 			if len(strings.Split(numbers, ",")) > 40 {
-				return errors.New("订单号不能超过 40 个")
+				return messageError("order_numbers_too_many")
 			}
 			return nil
 		}))),
-		validation.Field(&m.DeliveryStatus, validation.When(m.DeliveryStatus != "", validation.In(StatusPending, StatusNotFound, StatusTransit, StatusPickup, StatusDelivered, StatusExpired, StatusUndelivered, StatusException, StatusInfoReceived).Error("无效的发货状态"))),
-		validation.Field(&m.ArchivedStatus, validation.When(m.ArchivedStatus != "", validation.In("true", "false").Error("无效的归档状态"))),
+		validation.Field(&m.DeliveryStatus, validation.When(m.DeliveryStatus != "", validation.In(StatusPending, StatusNotFound, StatusTransit, StatusPickup, StatusDelivered, StatusExpired, StatusUndelivered, StatusException, StatusInfoReceived).ErrorObject(messageError("delivery_status_invalid")))),
+		validation.Field(&m.ArchivedStatus, validation.When(m.ArchivedStatus != "", validation.In("true", "false").ErrorObject(messageError("archived_status_invalid")))),
 		validation.Field(&m.CreatedDateMin, validation.When(m.CreatedDateMin != 0, validation.By(checkUnixTime))),
-		validation.Field(&m.CreatedDateMin, validation.When(m.CreatedDateMax != 0, validation.Required.Error("创建查询开始时间不能为空"))),
+		validation.Field(&m.CreatedDateMin, validation.When(m.CreatedDateMax != 0, validation.Required.ErrorObject(messageError("created_date_min_required")))),
 		validation.Field(&m.CreatedDateMax,
 			validation.When(m.CreatedDateMax != 0, validation.By(checkUnixTime)),
 			validation.When(m.CreatedDateMin != 0,
-				validation.Required.Error("创建查询结束时间不能为空"),
-				validation.Min(m.CreatedDateMin).Error("创建查询结束时间不能小于开始时间"),
+				validation.Required.ErrorObject(messageError("created_date_max_required")),
+				validation.Min(m.CreatedDateMin).ErrorObject(messageError("created_date_max_too_small")),
 			),
 		),
 		validation.Field(&m.ShippingDateMin, validation.When(m.ShippingDateMin != 0, validation.By(checkUnixTime))),
-		validation.Field(&m.ShippingDateMin, validation.When(m.ShippingDateMax != 0, validation.Required.Error("发货开始时间不能为空"))),
+		validation.Field(&m.ShippingDateMin, validation.When(m.ShippingDateMax != 0, validation.Required.ErrorObject(messageError("shipping_date_min_required")))),
 		validation.Field(&m.ShippingDateMax,
 			validation.When(m.ShippingDateMax != 0, validation.By(checkUnixTime)),
 			validation.When(m.ShippingDateMin != 0,
-				validation.Required.Error("发货结束时间不能为空"),
-				validation.Min(m.ShippingDateMin).Error("发货结束时间不能小于开始时间"),
+				validation.Required.ErrorObject(messageError("shipping_date_max_required")),
+				validation.Min(m.ShippingDateMin).ErrorObject(messageError("shipping_date_max_too_small")),
 			),
 		),
 		validation.Field(&m.UpdatedDateMin, validation.When(m.UpdatedDateMin != 0, validation.By(checkUnixTime))),
-		validation.Field(&m.UpdatedDateMin, validation.When(m.UpdatedDateMax != 0, validation.Required.Error("查询更新开始时间不能为空"))),
+		validation.Field(&m.UpdatedDateMin, validation.When(m.UpdatedDateMax != 0, validation.Required.ErrorObject(messageError("updated_date_min_required")))),
 		validation.Field(&m.UpdatedDateMax,
 			validation.When(m.UpdatedDateMax != 0, validation.By(checkUnixTime)),
 			validation.When(m.UpdatedDateMin != 0,
-				validation.Required.Error("查询更新结束时间不能为空"),
-				validation.Min(m.UpdatedDateMin).Error("查询更新结束时间不能小于开始时间"),
+				validation.Required.ErrorObject(messageError("updated_date_max_required")),
+				validation.Min(m.UpdatedDateMin).ErrorObject(messageError("updated_date_max_too_small")),
 			),
 		),
-		validation.Field(&m.Lang, validation.When(m.Lang != "", validation.In(ChineseLanguage, EnglishLanguage).Error("无效的查询结果语言"))),
+		validation.Field(&m.Lang, validation.When(m.Lang != "", validation.In(ChineseLanguage, EnglishLanguage).ErrorObject(messageError("lang_invalid")))),
 	)
 }
 
 func (s trackingService) Query(params TracksQueryParams) (items []Track, isLastPage bool, err error) {
-	if err = params.Validate(); err != nil {
+	if err = validate(params, s.config.Language); err != nil {
 		return
 	}
 
@@ -272,6 +335,71 @@ func (s trackingService) Query(params TracksQueryParams) (items []Track, isLastP
 	return
 }
 
+// TrackReader 包裹数据读取器，没有更多数据时返回 io.EOF
+type TrackReader interface {
+	Read() (Track, error)
+}
+
+type sliceTrackReader struct {
+	tracks []Track
+	index  int
+}
+
+func (r *sliceTrackReader) Read() (track Track, err error) {
+	if r.index >= len(r.tracks) {
+		return track, io.EOF
+	}
+	track = r.tracks[r.index]
+	r.index++
+	return
+}
+
+// NewSliceTrackReader 从包裹列表中读取数据
+func NewSliceTrackReader(tracks []Track) TrackReader {
+	return &sliceTrackReader{tracks: tracks}
+}
+
+type queryTrackReader struct {
+	service    TrackingService
+	params     TracksQueryParams
+	items      []Track
+	index      int
+	isLastPage bool
+}
+
+func (r *queryTrackReader) Read() (track Track, err error) {
+	for r.index >= len(r.items) {
+		if r.isLastPage {
+			return track, io.EOF
+		}
+		r.items, r.isLastPage, err = r.service.Query(r.params)
+		if err != nil {
+			return
+		}
+		r.index = 0
+		r.params.PagesAmount++
+	}
+	track = r.items[r.index]
+	r.index++
+	return
+}
+
+// Reader 返回逐页查询包裹数据的读取器
+func (s trackingService) Reader(params TracksQueryParams) TrackReader {
+	return NewQueryTrackReader(s, params)
+}
+
+// NewQueryTrackReader 通过 tracking 的 Query 逐页读取包裹数据，可用于实现 TrackingService 的 Reader
+func NewQueryTrackReader(tracking TrackingService, params TracksQueryParams) TrackReader {
+	if params.PagesAmount <= 0 {
+		params.PagesAmount = 1
+	}
+	if params.ItemsAmount <= 0 {
+		params.ItemsAmount = 100
+	}
+	return &queryTrackReader{service: tracking, params: params}
+}
+
 type trackingNumberCourierCode struct {
 	TrackingNumber string `json:"tracking_number"` // 包裹物流单号
 	CourierCode    string `json:"courier_code"`    // 物流商对应的唯一简码
@@ -284,30 +412,35 @@ type DeleteTrackRequest trackingNumberCourierCode
 type DeleteTrackRequests []DeleteTrackRequest
 
 func (m DeleteTrackRequests) Validate() error {
-	n := len(m)
-	if n == 0 {
-		return errors.New("请求数据不能为空")
-	} else if n > 40 {
-		return errors.New("请求数据不能超过 40 个")
-	}
-
-	var err error
-	for _, request := range m {
-		err = validation.ValidateStruct(&request,
-			validation.Field(&request.TrackingNumber, validation.Required.Error("包裹物流单号不能为空")),
-			validation.Field(&request.CourierCode, validation.Required.Error("物流商简码不能为空")),
-		)
-		if err != nil {
-			break
+	if err := validateBatchSize(len(m), 40); err != nil {
+		return err
+	}
+	if _, invalid := m.Partition(); invalid != nil {
+		return invalid
+	}
+	return nil
+}
+
+// Partition 拆分为通过验证和没有通过验证的数据（不检查数量），可以只提交通过验证的数据
+func (m DeleteTrackRequests) Partition() (valid DeleteTrackRequests, invalid *BatchError) {
+	for i, request := range m {
+		if err := validateTrackingNumberCourierCode(trackingNumberCourierCode(request)); err != nil {
+			invalid = invalid.add(i, request.TrackingNumber, err)
+		} else {
+			valid = append(valid, request)
 		}
 	}
-	return err
+	return
 }
 
-type DeleteTrackResult trackingNumberCourierCode
+type DeleteTrackResult struct {
+	trackingNumberCourierCode
+	ErrorCode    int    `json:"errorCode"`    // 失败时的错误代码
+	ErrorMessage string `json:"errorMessage"` // 失败时的错误信息
+}
 
 func (s trackingService) Delete(req DeleteTrackRequests) (success []DeleteTrackResult, error []DeleteTrackResult, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
@@ -336,31 +469,32 @@ type StopUpdateTrackRequest trackingNumberCourierCode
 type StopUpdateTrackRequests []StopUpdateTrackRequest
 
 func (m StopUpdateTrackRequests) Validate() error {
-	n := len(m)
-	if n == 0 {
-		return errors.New("请求数据不能为空")
-	} else if n > 40 {
-		return errors.New("请求数据不能超过 40 个")
-	}
-
-	var err error
-	for _, request := range m {
-		err = validation.ValidateStruct(&request,
-			validation.Field(&request.TrackingNumber, validation.Required.Error("包裹物流单号不能为空")),
-			validation.Field(&request.CourierCode, validation.Required.Error("物流商简码不能为空")),
-		)
-		if err != nil {
-			break
+	if err := validateBatchSize(len(m), 40); err != nil {
+		return err
+	}
+	if _, invalid := m.Partition(); invalid != nil {
+		return invalid
+	}
+	return nil
+}
+
+// Partition 拆分为通过验证和没有通过验证的数据（不检查数量），可以只提交通过验证的数据
+func (m StopUpdateTrackRequests) Partition() (valid StopUpdateTrackRequests, invalid *BatchError) {
+	for i, request := range m {
+		if err := validateTrackingNumberCourierCode(trackingNumberCourierCode(request)); err != nil {
+			invalid = invalid.add(i, request.TrackingNumber, err)
+		} else {
+			valid = append(valid, request)
 		}
 	}
-	return err
+	return
 }
 
 type StopUpdateResultSuccess trackingNumberCourierCode
 type StopUpdateResultError trackingNumberCourierCode
 
 func (s trackingService) StopUpdate(req StopUpdateTrackRequests) (success []StopUpdateResultSuccess, error []StopUpdateResultError, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
@@ -383,38 +517,139 @@ func (s trackingService) StopUpdate(req StopUpdateTrackRequests) (success []Stop
 	return
 }
 
+// 归档
+
+type ArchiveTrackRequest trackingNumberCourierCode
+type ArchiveTrackRequests []ArchiveTrackRequest
+
+func (m ArchiveTrackRequests) Validate() error {
+	if err := validateBatchSize(len(m), 40); err != nil {
+		return err
+	}
+	if _, invalid := m.Partition(); invalid != nil {
+		return invalid
+	}
+	return nil
+}
+
+// Partition 拆分为通过验证和没有通过验证的数据（不检查数量），可以只提交通过验证的数据
+func (m ArchiveTrackRequests) Partition() (valid ArchiveTrackRequests, invalid *BatchError) {
+	for i, request := range m {
+		if err := validateTrackingNumberCourierCode(trackingNumberCourierCode(request)); err != nil {
+			invalid = invalid.add(i, request.TrackingNumber, err)
+		} else {
+			valid = append(valid, request)
+		}
+	}
+	return
+}
+
+type ArchiveResultSuccess trackingNumberCourierCode
+type ArchiveResultError trackingNumberCourierCode
+
+func (s trackingService) archive(path string, req ArchiveTrackRequests) (success []ArchiveResultSuccess, error []ArchiveResultError, err error) {
+	if err = validate(req, s.config.Language); err != nil {
+		return
+	}
+
+	resp, err := s.httpClient.R().SetBody(req).Post(path)
+	if err != nil {
+		return
+	}
+
+	res := struct {
+		NormalResponse
+		Data struct {
+			Success []ArchiveResultSuccess `json:"success"`
+			Error   []ArchiveResultError   `json:"error"`
+		} `json:"data"`
+	}{}
+	if err = json.Unmarshal(resp.Body(), &res); err == nil {
+		success = res.Data.Success
+		error = res.Data.Error
+	}
+	return
+}
+
+// Archive 归档
+func (s trackingService) Archive(req ArchiveTrackRequests) (success []ArchiveResultSuccess, error []ArchiveResultError, err error) {
+	return s.archive("/archive", req)
+}
+
+// Unarchive 取消归档
+func (s trackingService) Unarchive(req ArchiveTrackRequests) (success []ArchiveResultSuccess, error []ArchiveResultError, err error) {
+	return s.archive("/unarchive", req)
+}
+
+// ArchiveDelivered 使用 tracking 归档签收时间（没有签收时间时使用最新物流信息的更新时间）超过 days 天的已签收包裹，每次最多提交 40 个
+//
+// days 小于 0 时返回的错误为中文信息，可以使用 TranslateError 翻译为其他语言。
+func ArchiveDelivered(tracking TrackingService, days int) (success []ArchiveResultSuccess, error []ArchiveResultError, err error) {
+	if days < 0 {
+		err = messageError("days_negative")
+		return
+	}
+	deadline := time.Now().AddDate(0, 0, -days)
+	reader := tracking.Reader(TracksQueryParams{DeliveryStatus: StatusDelivered, ArchivedStatus: "false"})
+	var requests ArchiveTrackRequests
+	for {
+		track, e := reader.Read()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			return success, error, e
+		}
+		deliveredAt := firstTime(track.LatestCheckpointTime, track.UpdateDate)
+		if track.Archived || deliveredAt.IsZero() || deliveredAt.After(deadline) {
+			continue
+		}
+		requests = append(requests, ArchiveTrackRequest{TrackingNumber: track.TrackingNumber, CourierCode: track.CourierCode})
+	}
+	for i := 0; i < len(requests); i += 40 {
+		j := i + 40
+		if j > len(requests) {
+			j = len(requests)
+		}
+		successItems, errorItems, e := tracking.Archive(requests[i:j])
+		if e != nil {
+			return success, error, e
+		}
+		success = append(success, successItems...)
+		error = append(error, errorItems...)
+	}
+	return
+}
+
 // 手动更新
 
 type RefreshTrackRequest trackingNumberCourierCode
 
 func (m RefreshTrackRequest) Validate() error {
-	return validation.ValidateStruct(&m,
-		validation.Field(&m.TrackingNumber, validation.Required.Error("包裹物流单号不能为空")),
-		validation.Field(&m.CourierCode, validation.Required.Error("物流商简码不能为空")),
-	)
+	return validateTrackingNumberCourierCode(trackingNumberCourierCode(m))
 }
 
 type RefreshTrackRequests []RefreshTrackRequest
 
 func (m RefreshTrackRequests) Validate() error {
-	n := len(m)
-	if n == 0 {
-		return errors.New("请求数据不能为空")
-	} else if n > 40 {
-		return errors.New("请求数据不能超过 40 个")
-	}
-
-	var err error
-	for _, request := range m {
-		err = validation.ValidateStruct(&request,
-			validation.Field(&request.TrackingNumber, validation.Required.Error("包裹物流单号不能为空")),
-			validation.Field(&request.CourierCode, validation.Required.Error("物流商简码不能为空")),
-		)
-		if err != nil {
-			break
+	if err := validateBatchSize(len(m), 40); err != nil {
+		return err
+	}
+	if _, invalid := m.Partition(); invalid != nil {
+		return invalid
+	}
+	return nil
+}
+
+// Partition 拆分为通过验证和没有通过验证的数据（不检查数量），可以只提交通过验证的数据
+func (m RefreshTrackRequests) Partition() (valid RefreshTrackRequests, invalid *BatchError) {
+	for i, request := range m {
+		if err := request.Validate(); err != nil {
+			invalid = invalid.add(i, request.TrackingNumber, err)
+		} else {
+			valid = append(valid, request)
 		}
 	}
-	return err
+	return
 }
 
 type RefreshResultSuccess struct {
@@ -428,7 +663,7 @@ type RefreshResultError struct {
 }
 
 func (s trackingService) Refresh(req RefreshTrackRequests) (success []RefreshResultSuccess, error []RefreshResultError, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
@@ -476,32 +711,34 @@ type StatusStatistic struct {
 func (m StatusStatisticRequest) Validate() error {
 	return validation.ValidateStruct(&m,
 		validation.Field(&m.CreatedDateMin, validation.When(m.CreatedDateMin != 0, validation.By(checkUnixTime))),
-		validation.Field(&m.CreatedDateMin, validation.When(m.CreatedDateMax != 0, validation.Required.Error("创建查询开始时间不能为空"))),
+		validation.Field(&m.CreatedDateMin, validation.When(m.CreatedDateMax != 0, validation.Required.ErrorObject(messageError("created_date_min_required")))),
 		validation.Field(&m.CreatedDateMax,
 			validation.When(m.CreatedDateMax != 0, validation.By(checkUnixTime)),
 			validation.When(m.CreatedDateMin != 0,
-				validation.Required.Error("创建查询结束时间不能为空"),
-				validation.Min(m.CreatedDateMin).Error("创建查询结束时间不能小于开始时间"),
+				validation.Required.ErrorObject(messageError("created_date_max_required")),
+				validation.Min(m.CreatedDateMin).ErrorObject(messageError("created_date_max_too_small")),
 			),
 		),
 		validation.Field(&m.ShippingDateMin, validation.When(m.ShippingDateMin != 0, validation.By(checkUnixTime))),
-		validation.Field(&m.ShippingDateMin, validation.When(m.ShippingDateMax != 0, validation.Required.Error("发货开始时间不能为空"))),
+		validation.Field(&m.ShippingDateMin, validation.When(m.ShippingDateMax != 0, validation.Required.ErrorObject(messageError("shipping_date_min_required")))),
 		validation.Field(&m.ShippingDateMax,
 			validation.When(m.ShippingDateMax != 0, validation.By(checkUnixTime)),
 			validation.When(m.ShippingDateMin != 0,
-				validation.Required.Error("发货结束时间不能为空"),
-				validation.Min(m.ShippingDateMin).Error("发货结束时间不能小于开始时间"),
+				validation.Required.ErrorObject(messageError("shipping_date_max_required")),
+				validation.Min(m.ShippingDateMin).ErrorObject(messageError("shipping_date_max_too_small")),
 			),
 		),
 	)
 }
 
 func (s trackingService) StatusStatistic(req StatusStatisticRequest) (stat StatusStatistic, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
-	resp, err := s.httpClient.R().SetBody(req).Get("/status")
+	resp, err := s.httpClient.R().
+		SetQueryParamsFromValues(toValues(req)).
+		Get("/status")
 	if err != nil {
 		return
 	}
@@ -533,38 +770,49 @@ type TransitTime struct {
 }
 
 type TransitTimeRequest struct {
-	CourierCode     string `json:"courier_code"`      // 物流商对应的唯一简码
-	OriginalCode    string `json:"original_code"`     // 发件国二字简码
-	DestinationCode string `json:"destination_code "` // 目的国的二字简码
+	CourierCode     string `json:"courier_code"`     // 物流商对应的唯一简码
+	OriginalCode    string `json:"original_code"`    // 发件国二字简码
+	DestinationCode string `json:"destination_code"` // 目的国的二字简码
 }
 
 type TransitTimeRequests []TransitTimeRequest
 
+func (m TransitTimeRequest) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.CourierCode, validation.Required.ErrorObject(messageError("courier_code_required"))),
+		validation.Field(&m.OriginalCode, validation.Required.ErrorObject(messageError("original_code_required")), countryCodeRule("original_code_invalid")),
+		validation.Field(&m.DestinationCode, validation.Required.ErrorObject(messageError("destination_code_required")), countryCodeRule("destination_code_invalid")),
+	)
+}
+
 func (m TransitTimeRequests) Validate() error {
-	if len(m) == 0 {
-		return errors.New("请求数据不能为空")
+	if err := validateBatchSize(len(m), 0); err != nil {
+		return err
+	}
+	if _, invalid := m.Partition(); invalid != nil {
+		return invalid
 	}
+	return nil
+}
 
-	var err error
-	for _, request := range m {
-		err = validation.ValidateStruct(&request,
-			validation.Field(&request.CourierCode, validation.Required.Error("物流商简码不能为空")),
-			validation.Field(&request.OriginalCode, validation.Required.Error("发件国二字简码不能为空")),
-			validation.Field(&request.DestinationCode, validation.Required.Error("目的国二字简码不能为空")),
-		)
-		if err != nil {
-			break
+// Partition 拆分为通过验证和没有通过验证的数据（不检查数量），可以只提交通过验证的数据
+func (m TransitTimeRequests) Partition() (valid TransitTimeRequests, invalid *BatchError) {
+	for i, request := range m {
+		if err := request.Validate(); err != nil {
+			invalid = invalid.add(i, "", err)
+		} else {
+			valid = append(valid, request)
 		}
 	}
-	return err
+	return
 }
 
 func (s trackingService) TransitTime(req TransitTimeRequests) (success []TransitTime, error []TransitTime, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
-	resp, err := s.httpClient.R().SetBody(req).Get("/transittime")
+	resp, err := s.httpClient.R().SetBody(req).Post("/transittime")
 	if err != nil {
 		return
 	}
@@ -593,7 +841,7 @@ type RemoteDetectionRequest struct {
 
 func (m RemoteDetectionRequest) Validate() error {
 	return validation.ValidateStruct(&m,
-		validation.Field(&m.PostalCode, validation.Required.Error("偏远地区邮编不能为空")),
+		validation.Field(&m.PostalCode, validation.Required.ErrorObject(messageError("postal_code_required"))),
 	)
 }
 
@@ -604,7 +852,7 @@ type RemoteDetectionResult struct {
 }
 
 func (s trackingService) RemoteDetection(req RemoteDetectionRequest) (item RemoteDetectionResult, err error) {
-	if err = req.Validate(); err != nil {
+	if err = validate(req, s.config.Language); err != nil {
 		return
 	}
 
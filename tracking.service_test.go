@@ -1,8 +1,12 @@
 package tracking51
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestTrackingService_Query(t *testing.T) {
@@ -34,3 +38,75 @@ func TestTrackingService_StatusStatistic(t *testing.T) {
 	}
 	t.Logf("%#v", stat)
 }
+
+func TestTrackingService_ArchiveDelivered(t *testing.T) {
+	var archived ArchiveTrackRequests
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get":
+			if r.URL.Query().Get("delivery_status") != StatusDelivered || r.URL.Query().Get("archived_status") != "false" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			old := time.Now().AddDate(0, 0, -40).Format("2006-01-02 15:04:05")
+			writeTestResponse(w, []Track{
+				{TrackingNumber: "A", CourierCode: "usps", DeliveryStatus: StatusDelivered, LatestCheckpointTime: old},
+				{TrackingNumber: "B", CourierCode: "usps", DeliveryStatus: StatusDelivered, LatestCheckpointTime: time.Now().Format("2006-01-02 15:04:05")},
+			})
+		case "/archive":
+			var req ArchiveTrackRequests
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &req)
+			archived = append(archived, req...)
+			writeTestResponse(w, map[string][]trackingNumberCourierCode{"success": {trackingNumberCourierCode(req[0])}})
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	})
+
+	success, _, err := ArchiveDelivered(c.Services.Tracking, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != 1 || archived[0].TrackingNumber != "A" || len(success) != 1 {
+		t.Errorf("unexpected archived: %#v, success: %#v", archived, success)
+	}
+
+	if _, _, err = c.Services.Tracking.Unarchive(make(ArchiveTrackRequests, 41)); err == nil {
+		t.Error("expected error for more than 40 requests")
+	}
+}
+
+func TestTrackingService_TransitTime(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/transittime" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		// GET 请求不会发送请求体
+		var req []map[string]string
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &req); err != nil || len(req) != 1 || req[0]["courier_code"] != "usps" {
+			t.Errorf("unexpected body: %s", b)
+		}
+		writeTestResponse(w, map[string][]TransitTime{
+			"success": {{CourierCode: "usps", OriginalCode: "CN", DestinationCode: "US", AverageDeliveryTime: 12}},
+		})
+	})
+
+	success, _, err := c.Services.Tracking.TransitTime(TransitTimeRequests{{CourierCode: "usps", OriginalCode: "CN", DestinationCode: "US"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(success) != 1 || success[0].AverageDeliveryTime != 12 {
+		t.Errorf("unexpected success: %#v", success)
+	}
+}
+
+func TestTransitTimeRequest_JSON(t *testing.T) {
+	b, err := json.Marshal(TransitTimeRequest{CourierCode: "usps", OriginalCode: "CN", DestinationCode: "US"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"courier_code":"usps","original_code":"CN","destination_code":"US"}` {
+		t.Errorf("unexpected json: %s", b)
+	}
+}
@@ -0,0 +1,386 @@
+package tracking51
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 物流节点翻译和规范化
+
+// Translator 翻译接口，lang 为目标语言（cn, en）
+type Translator interface {
+	Translate(text, lang string) (string, error)
+}
+
+// TranslatorFunc 使用函数作为翻译器（比如调用在线翻译服务）
+type TranslatorFunc func(text, lang string) (string, error)
+
+func (fn TranslatorFunc) Translate(text, lang string) (string, error) {
+	return fn(text, lang)
+}
+
+// 内置的物流常用短语（目标语言 => 原文 => 译文），原文可以是中文、英文或西班牙文
+var builtinPhrases = map[string]map[string]string{
+	EnglishLanguage: {
+		"电子信息已收到":                    "Shipment information received",
+		"已收寄":                        "Accepted",
+		"收寄":                         "Accepted",
+		"收寄计费信息":                     "Accepted",
+		"已揽收":                        "Picked up",
+		"揽收":                         "Picked up",
+		"运输中":                        "In transit",
+		"到达处理中心":                     "Arrived at processing center",
+		"离开处理中心":                     "Departed from processing center",
+		"已交航空公司运输":                   "Handed over to airline",
+		"航班起飞":                       "Flight departed",
+		"航班到达":                       "Flight arrived",
+		"已到达寄达地":                     "Arrived at destination",
+		"海关查验":                       "Customs inspection",
+		"清关中":                        "Customs clearance in progress",
+		"清关完成":                       "Customs clearance completed",
+		"海关扣留":                       "Held by customs",
+		"派送中":                        "Out for delivery",
+		"正在投递":                       "Out for delivery",
+		"待自取":                        "Available for pickup",
+		"待取件":                        "Available for pickup",
+		"投递失败":                       "Delivery failed",
+		"未妥投":                        "Delivery failed",
+		"妥投":                         "Delivered",
+		"已签收":                        "Delivered",
+		"退回":                         "Returned",
+		"退回寄件人":                      "Returned to sender",
+		"admitido":                   "Accepted",
+		"en tránsito":                "In transit",
+		"en transito":                "In transit",
+		"en aduana":                  "In customs",
+		"en reparto":                 "Out for delivery",
+		"entregado":                  "Delivered",
+		"devuelto al remitente":      "Returned to sender",
+		"disponible para recoger":    "Available for pickup",
+		"intento de entrega fallido": "Delivery attempt failed",
+	},
+	ChineseLanguage: {
+		"shipment information received":   "电子信息已收到",
+		"accepted":                        "已收寄",
+		"picked up":                       "已揽收",
+		"in transit":                      "运输中",
+		"arrived at processing center":    "到达处理中心",
+		"departed from processing center": "离开处理中心",
+		"handed over to airline":          "已交航空公司运输",
+		"flight departed":                 "航班起飞",
+		"flight arrived":                  "航班到达",
+		"arrived at destination":          "已到达寄达地",
+		"customs inspection":              "海关查验",
+		"customs clearance in progress":   "清关中",
+		"customs clearance completed":     "清关完成",
+		"held by customs":                 "海关扣留",
+		"out for delivery":                "派送中",
+		"available for pickup":            "待取件",
+		"delivery failed":                 "投递失败",
+		"delivery attempt failed":         "投递失败",
+		"delivered":                       "已签收",
+		"returned to sender":              "退回寄件人",
+		"admitido":                        "已收寄",
+		"en tránsito":                     "运输中",
+		"en transito":                     "运输中",
+		"en aduana":                       "清关中",
+		"en reparto":                      "派送中",
+		"entregado":                       "已签收",
+		"devuelto al remitente":           "退回寄件人",
+		"disponible para recoger":         "待取件",
+		"intento de entrega fallido":      "投递失败",
+	},
+}
+
+// DictionaryTranslator 基于短语词典的离线翻译器
+//
+// 原文与词典中的短语完全相同时直接返回译文，否则将原文按标点符号（逗号、分号、冒号、括号等）分为多个片段，
+// 只翻译与词典中的短语完全相同的片段，不会替换片段中的部分文字（比如 “Not delivered” 不会翻译为 “Not 已签收”），没有匹配的片段时返回原文。
+type DictionaryTranslator struct {
+	mu      sync.RWMutex
+	phrases map[string]map[string]string // 目标语言 => 原文（小写） => 译文
+}
+
+// NewDictionaryTranslator 创建包含内置物流常用短语的翻译器
+func NewDictionaryTranslator() *DictionaryTranslator {
+	t := &DictionaryTranslator{phrases: make(map[string]map[string]string)}
+	for lang, phrases := range builtinPhrases {
+		for source, translation := range phrases {
+			t.Add(lang, source, translation)
+		}
+	}
+	return t
+}
+
+// Add 添加短语，已存在的短语会被替换
+func (t *DictionaryTranslator) Add(lang, source, translation string) {
+	lang = strings.ToLower(lang)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phrases[lang] == nil {
+		t.phrases[lang] = make(map[string]string)
+	}
+	t.phrases[lang][strings.ToLower(NormalizeText(source))] = translation
+}
+
+// 物流详情中片段之间的分隔符
+var phraseDelimiterPattern = regexp.MustCompile(`[,，;；:：。【】\[\]()（）]|\s+-\s+`)
+
+func (t *DictionaryTranslator) Translate(text, lang string) (string, error) {
+	text = NormalizeText(text)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	phrases := t.phrases[strings.ToLower(lang)]
+	if v, ok := phrases[strings.ToLower(text)]; ok {
+		return v, nil
+	}
+
+	var sb strings.Builder
+	start := 0
+	segment := func(end int) {
+		seg := text[start:end]
+		source := strings.TrimSpace(seg)
+		if v, ok := phrases[strings.ToLower(source)]; ok && source != "" {
+			i := strings.Index(seg, source)
+			seg = seg[:i] + v + seg[i+len(source):]
+		}
+		sb.WriteString(seg)
+	}
+	for _, loc := range phraseDelimiterPattern.FindAllStringIndex(text, -1) {
+		segment(loc[0])
+		sb.WriteString(text[loc[0]:loc[1]])
+		start = loc[1]
+	}
+	segment(len(text))
+	return sb.String(), nil
+}
+
+// CachedTranslator 缓存翻译结果，适合包装调用在线翻译服务的翻译器
+type CachedTranslator struct {
+	translator Translator
+	size       int
+	mu         sync.Mutex
+	cache      map[string]string
+	keys       []string // 按添加顺序保存的缓存键，超过缓存数量时删除最早的缓存
+}
+
+// NewCachedTranslator 创建缓存翻译器，size 为最大缓存数量（小于等于 0 时为 10000）
+func NewCachedTranslator(translator Translator, size int) *CachedTranslator {
+	if size <= 0 {
+		size = 10000
+	}
+	return &CachedTranslator{
+		translator: translator,
+		size:       size,
+		cache:      make(map[string]string),
+	}
+}
+
+func (t *CachedTranslator) Translate(text, lang string) (string, error) {
+	key := lang + "\x00" + text
+	t.mu.Lock()
+	if v, ok := t.cache[key]; ok {
+		t.mu.Unlock()
+		return v, nil
+	}
+	t.mu.Unlock()
+
+	v, err := t.translator.Translate(text, lang)
+	if err != nil {
+		return text, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.cache[key]; !ok {
+		if len(t.keys) >= t.size {
+			delete(t.cache, t.keys[0])
+			t.keys = t.keys[1:]
+		}
+		t.keys = append(t.keys, key)
+	}
+	t.cache[key] = v
+	return v, nil
+}
+
+// NormalizeText 规范化文字：合并连续的空白字符，去除首尾空白，全部大写的英文转换为首字母大写
+func NormalizeText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if isUpperText(s) {
+		runes := []rune(strings.ToLower(s))
+		runes[0] = unicode.ToUpper(runes[0])
+		s = string(runes)
+	}
+	return s
+}
+
+// 是否全部为大写字母
+func isUpperText(s string) bool {
+	hasUpper := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			hasUpper = true
+		}
+	}
+	return hasUpper
+}
+
+// NormalizeLocation 规范化地址：合并连续的空白字符，全部大写的英文地址转换为每个单词首字母大写（两个字母的简码和包含数字的单词保持不变）
+func NormalizeLocation(s string) string {
+	words := strings.Fields(s)
+	if isUpperText(s) {
+		for i, word := range words {
+			runes := []rune(word)
+			if len(runes) <= 2 || strings.IndexFunc(word, unicode.IsDigit) != -1 {
+				continue
+			}
+			words[i] = string(runes[0]) + strings.ToLower(string(runes[1:]))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Location 物流节点地址
+type Location struct {
+	City    string `json:"city"`    // 城市
+	State   string `json:"state"`   // 州/省
+	Country string `json:"country"` // 国家（二字简码或者名称）
+}
+
+// 常见的国家名称（名称 => 二字简码）
+var locationCountries = map[string]string{
+	"china":          "CN",
+	"中国":             "CN",
+	"united states":  "US",
+	"usa":            "US",
+	"美国":             "US",
+	"united kingdom": "GB",
+	"uk":             "GB",
+	"英国":             "GB",
+	"germany":        "DE",
+	"德国":             "DE",
+	"france":         "FR",
+	"法国":             "FR",
+	"spain":          "ES",
+	"españa":         "ES",
+	"西班牙":            "ES",
+	"mexico":         "MX",
+	"méxico":         "MX",
+	"墨西哥":            "MX",
+	"canada":         "CA",
+	"加拿大":            "CA",
+	"australia":      "AU",
+	"澳大利亚":           "AU",
+	"japan":          "JP",
+	"日本":             "JP",
+	"hong kong":      "HK",
+	"香港":             "HK",
+}
+
+// 美国州和加拿大省的二字简码（简码 => 国家二字简码）
+var locationStates = map[string]string{
+	"AL": "US", "AK": "US", "AZ": "US", "AR": "US", "CA": "US", "CO": "US", "CT": "US", "DE": "US", "DC": "US",
+	"FL": "US", "GA": "US", "HI": "US", "ID": "US", "IL": "US", "IN": "US", "IA": "US", "KS": "US", "KY": "US",
+	"LA": "US", "ME": "US", "MD": "US", "MA": "US", "MI": "US", "MN": "US", "MS": "US", "MO": "US", "MT": "US",
+	"NE": "US", "NV": "US", "NH": "US", "NJ": "US", "NM": "US", "NY": "US", "NC": "US", "ND": "US", "OH": "US",
+	"OK": "US", "OR": "US", "PA": "US", "RI": "US", "SC": "US", "SD": "US", "TN": "US", "TX": "US", "UT": "US",
+	"VT": "US", "VA": "US", "WA": "US", "WV": "US", "WI": "US", "WY": "US", "PR": "US",
+	"AB": "CA", "BC": "CA", "MB": "CA", "NB": "CA", "NL": "CA", "NS": "CA", "NT": "CA", "NU": "CA", "ON": "CA",
+	"PE": "CA", "QC": "CA", "SK": "CA", "YT": "CA",
+}
+
+// 查找国家二字简码（与美国州或加拿大省简码相同的二字简码不作为国家简码，比如 CA 是加利福尼亚州，不是加拿大）
+func lookupLocationCountry(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if code, ok := locationCountries[strings.ToLower(s)]; ok {
+		return code, true
+	}
+	for _, c := range countries {
+		if strings.EqualFold(s, c.EnglishName) || s == c.ChineseName {
+			return c.Code, true
+		}
+	}
+	if len(s) == 2 && strings.ToUpper(s) == s && unicode.IsLetter(rune(s[0])) && unicode.IsLetter(rune(s[1])) {
+		if _, ok := locationStates[s]; !ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// 美国地址格式：CITY ST 12345
+var usLocationPattern = regexp.MustCompile(`^(.+?)\s+([A-Z]{2})\s+\d{5}(?:-\d{4})?$`)
+
+// ParseLocation 将物流节点地址解析为城市、州/省和国家（无法识别的部分保留在 City 中）
+//
+// 支持以下格式：“City, State, Country”、“City, Country”、“City, ST”、“CITY ST 12345”，
+// 最后一部分是美国州或加拿大省的二字简码时作为州/省，而不是国家（比如 “LOS ANGELES, CA” 中的 CA 是加利福尼亚州）
+func ParseLocation(s string) Location {
+	s = strings.Join(strings.Fields(s), " ")
+	var parts []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	var loc Location
+	if n := len(parts); n > 1 {
+		if country, ok := locationStates[parts[n-1]]; ok {
+			loc.City = NormalizeLocation(strings.Join(parts[:n-1], ", "))
+			loc.State, loc.Country = parts[n-1], country
+			return loc
+		}
+		if code, ok := lookupLocationCountry(parts[n-1]); ok {
+			loc.Country = code
+			parts = parts[:n-1]
+		}
+	}
+	switch len(parts) {
+	case 0:
+	case 1:
+		if m := usLocationPattern.FindStringSubmatch(parts[0]); m != nil {
+			loc.City, loc.State = m[1], m[2]
+			if loc.Country == "" {
+				loc.Country = "US"
+			}
+		} else if code, ok := lookupLocationCountry(parts[0]); ok && loc.Country == "" && len(parts[0]) > 2 {
+			loc.Country = code
+		} else {
+			loc.City = parts[0]
+		}
+	default:
+		loc.City = parts[0]
+		loc.State = strings.Join(parts[1:], ", ")
+	}
+	loc.City = NormalizeLocation(loc.City)
+	return loc
+}
+
+// TranslateTrack 翻译包裹所有物流节点的详情和地址，返回翻译后的包裹数据（不修改原数据），翻译失败时返回原包裹数据和错误
+func TranslateTrack(track Track, translator Translator, lang string) (Track, error) {
+	translated := track
+	for _, info := range []*Information{&translated.OriginInfo, &translated.DestinationInfo} {
+		items := make([]TrackInformation, len(info.TrackInfo))
+		for i, item := range info.TrackInfo {
+			var err error
+			if item.TrackingDetail, err = translator.Translate(NormalizeText(item.TrackingDetail), lang); err != nil {
+				return track, err
+			}
+			if item.Location != "" {
+				if item.Location, err = translator.Translate(NormalizeLocation(item.Location), lang); err != nil {
+					return track, err
+				}
+			}
+			items[i] = item
+		}
+		info.TrackInfo = items
+	}
+	return translated, nil
+}
@@ -0,0 +1,88 @@
+package tracking51
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDictionaryTranslator(t *testing.T) {
+	translator := NewDictionaryTranslator()
+	for _, v := range []struct{ text, lang, expected string }{
+		{"已签收", EnglishLanguage, "Delivered"},
+		{"【深圳市】到达处理中心", EnglishLanguage, "【深圳市】Arrived at processing center"},
+		{"ENTREGADO", EnglishLanguage, "Delivered"},
+		{"  Out   for delivery ", ChineseLanguage, "派送中"},
+		{"Undelivered", ChineseLanguage, "Undelivered"},
+		{"Not delivered", ChineseLanguage, "Not delivered"},
+		{"Package not in transit yet", ChineseLanguage, "Package not in transit yet"},
+		{"Los Angeles, CA: Out for delivery", ChineseLanguage, "Los Angeles, CA: 派送中"},
+		{"未妥投", EnglishLanguage, "Delivery failed"},
+		{"包裹未妥投，请联系", EnglishLanguage, "包裹未妥投，请联系"},
+		{"Disponible para recoger", ChineseLanguage, "待取件"},
+		{"INTENTO DE ENTREGA FALLIDO", ChineseLanguage, "投递失败"},
+	} {
+		if s, _ := translator.Translate(v.text, v.lang); s != v.expected {
+			t.Errorf("%s: expected %s, got %s", v.text, v.expected, s)
+		}
+	}
+
+	calls := 0
+	cached := NewCachedTranslator(TranslatorFunc(func(text, lang string) (string, error) {
+		calls++
+		return translator.Translate(text, lang)
+	}), 1)
+	cached.Translate("已签收", EnglishLanguage)
+	cached.Translate("已签收", EnglishLanguage)
+	cached.Translate("派送中", EnglishLanguage)
+	cached.Translate("已签收", EnglishLanguage)
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	for s, expected := range map[string]Location{
+		"LOS ANGELES, CA, US":  {City: "Los Angeles", State: "CA", Country: "US"},
+		"Shenzhen, China":      {City: "Shenzhen", Country: "CN"},
+		"NEW YORK NY 10001":    {City: "New York", State: "NY", Country: "US"},
+		"深圳市":                  {City: "深圳市"},
+		"  Madrid ,  España  ": {City: "Madrid", Country: "ES"},
+		"LOS ANGELES, CA":      {City: "Los Angeles", State: "CA", Country: "US"},
+		"TORONTO, ON":          {City: "Toronto", State: "ON", Country: "CA"},
+		"Sao Paulo, BR":        {City: "Sao Paulo", Country: "BR"},
+	} {
+		if loc := ParseLocation(s); loc != expected {
+			t.Errorf("%s: expected %#v, got %#v", s, expected, loc)
+		}
+	}
+}
+
+func TestTranslateTrack(t *testing.T) {
+	track := Track{OriginInfo: Information{TrackInfo: []TrackInformation{{TrackingDetail: "妥投", Location: "LOS ANGELES CA"}}}}
+	translated, err := TranslateTrack(track, NewDictionaryTranslator(), EnglishLanguage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := translated.OriginInfo.TrackInfo[0]; c.TrackingDetail != "Delivered" || c.Location != "Los Angeles CA" {
+		t.Errorf("unexpected checkpoint: %#v", c)
+	}
+	if track.OriginInfo.TrackInfo[0].TrackingDetail != "妥投" {
+		t.Error("original track should not be modified")
+	}
+
+	// 翻译失败时返回原包裹数据
+	track.DestinationInfo.TrackInfo = []TrackInformation{{TrackingDetail: "Delivered"}}
+	failed := errors.New("failed")
+	translated, err = TranslateTrack(track, TranslatorFunc(func(text, lang string) (string, error) {
+		if text == "Delivered" {
+			return "", failed
+		}
+		return NewDictionaryTranslator().Translate(text, lang)
+	}), EnglishLanguage)
+	if err != failed {
+		t.Errorf("expected error %v, got %v", failed, err)
+	}
+	if translated.OriginInfo.TrackInfo[0].TrackingDetail != "妥投" {
+		t.Errorf("expected the original track, got %#v", translated.OriginInfo.TrackInfo[0])
+	}
+}
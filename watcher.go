@@ -0,0 +1,261 @@
+package tracking51
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 轮询监控包裹
+
+// UpdateInterval 根据包裹添加的时长返回 51Tracking 的更新频率（取下限），超过 80 天 51Tracking 将不再更新，此时 ok 为 false
+//
+//   - 30 天内：4 小时
+//   - 30~45 天：6 小时
+//   - 45~60 天：12 小时
+//   - 60~80 天：24 小时
+func UpdateInterval(age time.Duration) (interval time.Duration, ok bool) {
+	days := age.Hours() / 24
+	switch {
+	case days < 30:
+		return 4 * time.Hour, true
+	case days < 45:
+		return 6 * time.Hour, true
+	case days < 60:
+		return 12 * time.Hour, true
+	case days < 80:
+		return 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// WatcherOptions 监控选项
+type WatcherOptions struct {
+	Interval           time.Duration                           // 检查是否有需要查询的包裹的间隔时间，默认为 1 分钟
+	RetryInterval      time.Duration                           // 查询失败后再次查询的间隔时间，默认为 5 分钟
+	OnChange           func(event TrackEvent)                  // 包裹有变化时的回调
+	OnStatusTransition func(event TrackEvent, from, to string) // 包裹状态变化时的回调
+	OnStop             func(track Track)                       // 包裹停止监控时的回调（已签收、运输过久或者超过 80 天）
+	Alerts             *AlertEngine                            // 告警规则引擎，每次查询后检查包裹
+	OnAlert            func(report AlertReport)                // 包裹触发告警时的回调
+	Logger             *log.Logger                             // 日志记录器（可以使用 Tracking51.Logger()），默认输出到标准输出
+}
+
+type watchedParcel struct {
+	trackingNumber string
+	courierCode    string
+	addedAt        time.Time
+	track          Track
+	nextCheck      time.Time
+}
+
+// Watcher 定时查询包裹，适用于无法接收 Webhook 推送的环境
+//
+// 包裹的查询间隔遵循 51Tracking 的更新频率（参考 UpdateInterval），包裹签收、运输过久或者超过 80 天后自动停止监控。
+type Watcher struct {
+	tracking TrackingService
+	options  WatcherOptions
+	mu       sync.Mutex
+	parcels  map[string]*watchedParcel
+	now      func() time.Time
+}
+
+func NewWatcher(tracking TrackingService, options WatcherOptions) *Watcher {
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = 5 * time.Minute
+	}
+	if options.Logger == nil {
+		options.Logger = defaultLogger
+	}
+	return &Watcher{
+		tracking: tracking,
+		options:  options,
+		parcels:  make(map[string]*watchedParcel),
+		now:      time.Now,
+	}
+}
+
+// Add 添加需要监控的包裹，courierCode 为空时匹配该单号的所有物流商
+func (w *Watcher) Add(trackingNumber, courierCode string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := trackKey(trackingNumber, courierCode)
+	if _, ok := w.parcels[key]; !ok {
+		w.parcels[key] = &watchedParcel{
+			trackingNumber: trackingNumber,
+			courierCode:    courierCode,
+			addedAt:        w.now(),
+		}
+	}
+}
+
+// Remove 移除监控的包裹
+func (w *Watcher) Remove(trackingNumber, courierCode string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.parcels, trackKey(trackingNumber, courierCode))
+}
+
+// Len 监控中的包裹数量
+func (w *Watcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.parcels)
+}
+
+// 查找查询结果对应的监控包裹
+func (w *Watcher) find(track Track) (string, *watchedParcel) {
+	key := trackKey(track.TrackingNumber, track.CourierCode)
+	if p, ok := w.parcels[key]; ok {
+		return key, p
+	}
+	key = trackKey(track.TrackingNumber, "")
+	return key, w.parcels[key]
+}
+
+// Poll 查询所有到期的包裹（每次最多查询 40 个单号）
+//
+// 查询成功后才会更新包裹的下次查询时间，查询失败的包裹在 RetryInterval 后再次查询，其他单号会继续查询，返回第一个错误。
+func (w *Watcher) Poll() error {
+	now := w.now()
+	w.mu.Lock()
+	var numbers []string
+	var stopped []Track
+	due := make(map[string][]string) // 单号 => 到期的包裹
+	for key, p := range w.parcels {
+		if p.nextCheck.After(now) {
+			continue
+		}
+		// 超过 80 天 51Tracking 不再更新，停止监控
+		if _, ok := UpdateInterval(now.Sub(w.addedAt(p))); !ok {
+			delete(w.parcels, key)
+			track := p.track
+			if track.TrackingNumber == "" {
+				track.TrackingNumber, track.CourierCode = p.trackingNumber, p.courierCode
+			}
+			stopped = append(stopped, track)
+			continue
+		}
+		if _, ok := due[p.trackingNumber]; !ok {
+			numbers = append(numbers, p.trackingNumber)
+		}
+		due[p.trackingNumber] = append(due[p.trackingNumber], key)
+	}
+	w.mu.Unlock()
+	if w.options.OnStop != nil {
+		for _, track := range stopped {
+			w.options.OnStop(track)
+		}
+	}
+
+	var err error
+	for start := 0; start < len(numbers); start += 40 {
+		end := start + 40
+		if end > len(numbers) {
+			end = len(numbers)
+		}
+		items, _, e := w.tracking.Query(TracksQueryParams{
+			TrackingNumbers: strings.Join(numbers[start:end], ","),
+		})
+		if e != nil {
+			w.schedule(due, numbers[start:end], now, now.Add(w.options.RetryInterval))
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+
+		for _, item := range items {
+			w.handle(item, now)
+		}
+		w.schedule(due, numbers[start:end], now, time.Time{})
+	}
+	return err
+}
+
+// 设置本次查询后还没有设置下次查询时间的包裹（查询失败或者没有返回查询结果），next 为零值时按添加时长计算下次查询时间
+func (w *Watcher) schedule(due map[string][]string, numbers []string, now, next time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, number := range numbers {
+		for _, key := range due[number] {
+			p, ok := w.parcels[key]
+			if !ok || p.nextCheck.After(now) {
+				continue
+			}
+			if next.IsZero() {
+				interval, _ := UpdateInterval(now.Sub(w.addedAt(p)))
+				p.nextCheck = now.Add(interval)
+			} else {
+				p.nextCheck = next
+			}
+		}
+	}
+}
+
+// 包裹添加到 51Tracking 的时间，没有查询结果时使用添加监控的时间
+func (w *Watcher) addedAt(p *watchedParcel) time.Time {
+	if createdAt, ok := parseTime(p.track.CreatedAt); ok {
+		return createdAt
+	}
+	return p.addedAt
+}
+
+func (w *Watcher) handle(track Track, now time.Time) {
+	w.mu.Lock()
+	key, p := w.find(track)
+	if p == nil {
+		w.mu.Unlock()
+		return
+	}
+	event := NewTrackEvent(PollingEventSource, p.track, track)
+	p.track = track
+
+	interval, ok := UpdateInterval(now.Sub(w.addedAt(p)))
+	stop := !ok || track.DeliveryStatus == StatusDelivered || track.DeliveryStatus == StatusExpired
+	if stop {
+		delete(w.parcels, key)
+	} else {
+		p.nextCheck = now.Add(interval)
+	}
+	w.mu.Unlock()
+
+	if event.HasChanges() {
+		if w.options.OnChange != nil {
+			w.options.OnChange(event)
+		}
+		if from, to, ok := event.StatusTransition(); ok && w.options.OnStatusTransition != nil {
+			w.options.OnStatusTransition(event, from, to)
+		}
+	}
+	if w.options.Alerts != nil && w.options.OnAlert != nil {
+		if report := w.options.Alerts.Evaluate(track); report.HasAlerts() {
+			w.options.OnAlert(report)
+		}
+	}
+	if stop && w.options.OnStop != nil {
+		w.options.OnStop(track)
+	}
+}
+
+// Run 持续监控包裹，直到 ctx 被取消
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Poll(); err != nil {
+			w.options.Logger.Printf("Watcher poll error: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
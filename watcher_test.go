@@ -0,0 +1,139 @@
+package tracking51
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateInterval(t *testing.T) {
+	day := 24 * time.Hour
+	for age, expected := range map[time.Duration]time.Duration{
+		day:      4 * time.Hour,
+		35 * day: 6 * time.Hour,
+		50 * day: 12 * time.Hour,
+		70 * day: 24 * time.Hour,
+		90 * day: 0,
+	} {
+		if interval, _ := UpdateInterval(age); interval != expected {
+			t.Errorf("age %s: expected %s, got %s", age, expected, interval)
+		}
+	}
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	status := StatusTransit
+	queries := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var items []Track
+		for _, number := range strings.Split(r.URL.Query().Get("tracking_numbers"), ",") {
+			items = append(items, Track{TrackingNumber: number, CourierCode: "usps", DeliveryStatus: status})
+		}
+		writeTestResponse(w, items)
+	})
+
+	var transitions []string
+	stopped := 0
+	watcher := NewWatcher(c.Services.Tracking, WatcherOptions{
+		OnStatusTransition: func(event TrackEvent, from, to string) {
+			transitions = append(transitions, event.TrackingNumber+":"+from+"->"+to)
+		},
+		OnStop: func(track Track) { stopped++ },
+	})
+	now := time.Now()
+	watcher.now = func() time.Time { return now }
+	for i := 0; i < 45; i++ {
+		watcher.Add(strings.Repeat("A", i+1), "usps")
+	}
+	if err := watcher.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if queries != 2 || len(transitions) != 45 {
+		t.Fatalf("expected 2 queries and 45 transitions, got %d and %d", queries, len(transitions))
+	}
+
+	// 未到下次查询时间
+	if err := watcher.Poll(); err != nil || queries != 2 {
+		t.Fatalf("parcels should not be queried before next check, queries: %d", queries)
+	}
+
+	now = now.Add(4 * time.Hour)
+	status = StatusDelivered
+	if err := watcher.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if stopped != 45 || watcher.Len() != 0 {
+		t.Errorf("delivered parcels should be stopped, stopped: %d, watching: %d", stopped, watcher.Len())
+	}
+}
+
+func TestWatcher_PollExpired(t *testing.T) {
+	queries := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		writeTestResponse(w, []Track{})
+	})
+
+	var stopped []Track
+	watcher := NewWatcher(c.Services.Tracking, WatcherOptions{
+		OnStop: func(track Track) { stopped = append(stopped, track) },
+	})
+	now := time.Now()
+	watcher.now = func() time.Time { return now }
+	watcher.Add("A", "usps")
+	if err := watcher.Poll(); err != nil || queries != 1 {
+		t.Fatalf("expected 1 query, got %d, error: %v", queries, err)
+	}
+
+	// 超过 80 天仍没有查询结果的包裹停止监控，不再查询
+	now = now.AddDate(0, 0, 81)
+	for i := 0; i < 2; i++ {
+		if err := watcher.Poll(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if queries != 1 || watcher.Len() != 0 {
+		t.Errorf("expired parcel should not be queried, queries: %d, watching: %d", queries, watcher.Len())
+	}
+	if len(stopped) != 1 || stopped[0].TrackingNumber != "A" || stopped[0].CourierCode != "usps" {
+		t.Errorf("unexpected stopped tracks: %#v", stopped)
+	}
+}
+
+func TestWatcher_PollRetry(t *testing.T) {
+	queries := 0
+	fail := true
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		if fail {
+			w.Write([]byte(`{"code":503,"message":"Internal error","data":[]}`))
+			return
+		}
+		writeTestResponse(w, []Track{{TrackingNumber: "A", CourierCode: "usps", DeliveryStatus: StatusTransit}})
+	})
+
+	watcher := NewWatcher(c.Services.Tracking, WatcherOptions{RetryInterval: 5 * time.Minute})
+	now := time.Now()
+	watcher.now = func() time.Time { return now }
+	watcher.Add("A", "usps")
+	if err := watcher.Poll(); err == nil {
+		t.Fatal("expected query error")
+	}
+
+	// 查询失败后在 RetryInterval 后再次查询，而不是等待 4 小时
+	now = now.Add(time.Minute)
+	if err := watcher.Poll(); err != nil || queries != 1 {
+		t.Fatalf("parcel should not be queried before retry, queries: %d, error: %v", queries, err)
+	}
+	now = now.Add(5 * time.Minute)
+	fail = false
+	if err := watcher.Poll(); err != nil || queries != 2 {
+		t.Fatalf("parcel should be queried after retry interval, queries: %d, error: %v", queries, err)
+	}
+	now = now.Add(time.Hour)
+	if err := watcher.Poll(); err != nil || queries != 2 {
+		t.Errorf("parcel should not be queried before next check, queries: %d, error: %v", queries, err)
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"github.com/hiscaler/gox/stringx"
+	"io"
+	"net/http"
 	"strconv"
+	"sync"
+	"time"
 )
 
 // Webhook 数据处理
@@ -32,7 +38,178 @@ func (wr Webhook) Valid(email string) bool {
 	if wr.Verify.Timestamp == 0 || wr.Verify.Signature == "" {
 		return false
 	}
+	return webhookSignature(email, wr.Verify.Timestamp) == wr.Verify.Signature
+}
+
+// 使用用户邮箱对推送时间签名
+func webhookSignature(email string, timestamp int) string {
 	hash := hmac.New(sha256.New, stringx.ToBytes(email))
-	hash.Write(stringx.ToBytes(strconv.Itoa(wr.Verify.Timestamp)))
-	return hex.EncodeToString(hash.Sum(nil)) == wr.Verify.Signature
+	hash.Write(stringx.ToBytes(strconv.Itoa(timestamp)))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Event 与上次保存的包裹数据比较，生成包裹变化事件
+func (wr Webhook) Event(previous Track) TrackEvent {
+	return NewTrackEvent(WebhookEventSource, previous, wr.Track)
+}
+
+// 推送防重放
+
+var (
+	ErrWebhookInvalidSignature = newMessageError("webhook_signature_invalid")
+	ErrWebhookExpired          = newMessageError("webhook_expired")
+)
+
+// ReplayStore 已处理推送的存储接口
+type ReplayStore interface {
+	// Remember 记录 key（有效期为 ttl），key 已存在且未过期时 duplicate 为 true
+	Remember(key string, ttl time.Duration) (duplicate bool, err error)
+	// Forget 删除 key，推送处理失败时调用，以便 51Tracking 重试推送时可以再次处理
+	Forget(key string) error
+}
+
+// 每添加多少个 key 清理一次过期的 key
+const memoryReplayStoreSweepInterval = 1024
+
+// MemoryReplayStore 内存存储
+//
+// 查找时判断 key 是否过期，每添加 1024 个 key 清理一次所有过期的 key
+type MemoryReplayStore struct {
+	mu      sync.Mutex
+	items   map[string]time.Time // key => 过期时间
+	inserts int                  // 上次清理后添加的 key 数量
+	now     func() time.Time
+}
+
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{
+		items: make(map[string]time.Time),
+		now:   time.Now,
+	}
+}
+
+func (s *MemoryReplayStore) Remember(key string, ttl time.Duration) (duplicate bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if expiredAt, ok := s.items[key]; ok && expiredAt.After(now) {
+		return true, nil
+	}
+	s.items[key] = now.Add(ttl)
+	s.inserts++
+	if s.inserts >= memoryReplayStoreSweepInterval {
+		s.inserts = 0
+		for k, expiredAt := range s.items {
+			if !expiredAt.After(now) {
+				delete(s.items, k)
+			}
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryReplayStore) Forget(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+// WebhookHandlerOptions 推送处理选项
+type WebhookHandlerOptions struct {
+	Email     string                   // 51Tracking 用户邮箱，用于验证推送签名
+	Window    time.Duration            // 推送时间（verify.timestamp）的有效期，超过有效期的推送将被拒绝，默认为 30 分钟
+	Store     ReplayStore              // 已处理推送的存储，默认使用内存存储
+	OnWebhook func(wh Webhook) error   // 推送处理回调，同一推送只会回调一次，返回错误时 51Tracking 会重新推送
+	Sink      WebhookSink              // 推送处理完成后的转发目标（比如 FanOut）
+	Alerts    *AlertEngine             // 告警规则引擎，推送处理完成后检查包裹
+	OnAlert   func(report AlertReport) // 包裹触发告警时的回调
+	Language  string                   // 错误信息（包括 ServeHTTP 返回的错误信息）的语言，默认为中文
+}
+
+// WebhookHandler 推送处理器
+//
+// 校验推送签名和推送时间，并根据（单号、推送时间、签名）对 51Tracking 的重复推送去重，保证每个推送只处理一次。
+type WebhookHandler struct {
+	options WebhookHandlerOptions
+	now     func() time.Time
+}
+
+func NewWebhookHandler(options WebhookHandlerOptions) *WebhookHandler {
+	if options.Window <= 0 {
+		options.Window = 30 * time.Minute
+	}
+	if options.Store == nil {
+		options.Store = NewMemoryReplayStore()
+	}
+	return &WebhookHandler{options: options, now: time.Now}
+}
+
+func webhookReplayKey(wh Webhook) string {
+	return wh.TrackingNumber + "|" + strconv.Itoa(wh.Verify.Timestamp) + "|" + wh.Verify.Signature
+}
+
+// Handle 解析并校验推送数据，duplicate 为 true 表示该推送已经处理过
+func (h *WebhookHandler) Handle(body []byte) (wr WebhookRequest, duplicate bool, err error) {
+	if err = json.Unmarshal(body, &wr); err != nil {
+		return
+	}
+	if !wr.Data.Valid(h.options.Email) {
+		return wr, false, TranslateError(ErrWebhookInvalidSignature, h.options.Language)
+	}
+	d := h.now().Sub(time.Unix(int64(wr.Data.Verify.Timestamp), 0))
+	if d < 0 {
+		d = -d
+	}
+	if d > h.options.Window {
+		return wr, false, TranslateError(ErrWebhookExpired, h.options.Language)
+	}
+
+	key := webhookReplayKey(wr.Data)
+	// 过期的推送会被拒绝，所以只需要记住有效期内的推送
+	if duplicate, err = h.options.Store.Remember(key, 2*h.options.Window); err != nil || duplicate {
+		return
+	}
+	if h.options.OnWebhook != nil {
+		err = h.options.OnWebhook(wr.Data)
+	}
+	if err == nil && h.options.Sink != nil {
+		err = h.options.Sink.Send(wr.Data)
+	}
+	if err != nil {
+		h.options.Store.Forget(key)
+		return
+	}
+	if h.options.Alerts != nil && h.options.OnAlert != nil {
+		if report := h.options.Alerts.Evaluate(wr.Data.Track); report.HasAlerts() {
+			h.options.OnAlert(report)
+		}
+	}
+	return
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, _, err = h.Handle(body)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, ErrWebhookInvalidSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrWebhookExpired):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		var syntaxError *json.SyntaxError
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &syntaxError) || errors.As(err, &typeError) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
 }
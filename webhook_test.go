@@ -0,0 +1,101 @@
+package tracking51
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testWebhookBody(email string, timestamp int) []byte {
+	wr := WebhookRequest{Code: Success, Message: "Success"}
+	wr.Data.TrackingNumber = "LX123456789CN"
+	wr.Data.Verify.Timestamp = timestamp
+	wr.Data.Verify.Signature = webhookSignature(email, timestamp)
+	b, _ := json.Marshal(wr)
+	return b
+}
+
+func TestWebhookHandler_Handle(t *testing.T) {
+	email := "test@example.com"
+	calls := 0
+	fail := true
+	h := NewWebhookHandler(WebhookHandlerOptions{
+		Email: email,
+		OnWebhook: func(wh Webhook) error {
+			calls++
+			if fail {
+				return errors.New("downstream error")
+			}
+			return nil
+		},
+	})
+
+	body := testWebhookBody(email, int(time.Now().Unix()))
+	if _, _, err := h.Handle(body); err == nil {
+		t.Fatal("expected callback error")
+	}
+	// 处理失败后重试推送可以再次处理
+	fail = false
+	if _, duplicate, err := h.Handle(body); err != nil || duplicate {
+		t.Fatalf("unexpected result: duplicate %v, error %v", duplicate, err)
+	}
+	if _, duplicate, err := h.Handle(body); err != nil || !duplicate {
+		t.Fatalf("expected duplicate, error %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+
+	if _, _, err := h.Handle(testWebhookBody(email, int(time.Now().Add(-time.Hour).Unix()))); !errors.Is(err, ErrWebhookExpired) {
+		t.Errorf("expected expired error, got %v", err)
+	}
+	if _, _, err := h.Handle(testWebhookBody("other@example.com", int(time.Now().Unix()))); !errors.Is(err, ErrWebhookInvalidSignature) {
+		t.Errorf("expected invalid signature error, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("{")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+
+	h = NewWebhookHandler(WebhookHandlerOptions{Email: email, Language: EnglishLanguage})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(string(testWebhookBody("other@example.com", int(time.Now().Unix()))))))
+	if w.Code != http.StatusUnauthorized || strings.TrimSpace(w.Body.String()) != Message(EnglishLanguage, "webhook_signature_invalid") {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryReplayStore()
+	s.now = func() time.Time { return now }
+	if duplicate, _ := s.Remember("a", time.Minute); duplicate {
+		t.Error("a should not be duplicate")
+	}
+	if duplicate, _ := s.Remember("a", time.Minute); !duplicate {
+		t.Error("a should be duplicate")
+	}
+
+	// 过期的 key 在查找时失效
+	now = now.Add(2 * time.Minute)
+	if duplicate, _ := s.Remember("a", time.Minute); duplicate {
+		t.Error("expired a should not be duplicate")
+	}
+
+	// 添加一定数量的 key 后清理过期的 key
+	for i := s.inserts; i < memoryReplayStoreSweepInterval-1; i++ {
+		s.Remember(strconv.Itoa(i), time.Minute)
+	}
+	now = now.Add(2 * time.Minute)
+	s.Remember("b", time.Minute)
+	if len(s.items) != 1 {
+		t.Errorf("expected 1 item after sweep, got %d", len(s.items))
+	}
+}